@@ -1,8 +1,20 @@
 package main
 
-import "gleam/internal/ui"
+import (
+	"os"
+
+	"gleam/internal/askpass"
+	"gleam/internal/cli"
+	"gleam/internal/ui"
+)
 
 func main() {
-	app := ui.NewGleamApp()
-	app.Run()
+	if askpass.Requested() {
+		os.Exit(askpass.Main(os.Args[1:]))
+	}
+	if cli.Requested(os.Args[1:]) {
+		os.Exit(cli.Main(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	ui.NewWorkspace().Run()
 }
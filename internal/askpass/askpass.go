@@ -0,0 +1,120 @@
+// Package askpass lets git and ssh ask the running Gleam window for credentials.
+// The Gleam binary is registered as GIT_ASKPASS and SSH_ASKPASS, and when it is
+// started in askpass mode it forwards the prompt over a local socket
+package askpass
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// SocketEnv names the environment variable that carries the socket path to
+// the askpass helper and marks a process as running in askpass mode
+const SocketEnv = "GLEAM_ASKPASS_SOCKET"
+
+// PromptFunc asks the user to answer prompt and reports false if they canceled
+type PromptFunc func(prompt string) (string, bool)
+
+type request struct {
+	Prompt string `json:"prompt"`
+}
+
+type response struct {
+	Answer   string `json:"answer"`
+	Canceled bool   `json:"canceled"`
+}
+
+// Server answers askpass requests by calling its PromptFunc
+type Server struct {
+	dir        string
+	socketPath string
+	executable string
+	listener   net.Listener
+	prompt     PromptFunc
+}
+
+// NewServer starts listening for askpass requests on a private socket
+func NewServer(prompt PromptFunc) (*Server, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "gleam-askpass-")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	socketPath := filepath.Join(dir, "askpass.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	server := &Server{
+		dir:        dir,
+		socketPath: socketPath,
+		executable: executable,
+		listener:   listener,
+		prompt:     prompt,
+	}
+	go server.serve()
+	return server, nil
+}
+
+// Env returns the environment variables that route git and ssh credential
+// prompts to this server
+func (s *Server) Env() []string {
+	return []string{
+		"GIT_ASKPASS=" + s.executable,
+		"SSH_ASKPASS=" + s.executable,
+		"SSH_ASKPASS_REQUIRE=force",
+		"GIT_TERMINAL_PROMPT=0",
+		SocketEnv + "=" + s.socketPath,
+	}
+}
+
+// Close stops the server and removes its socket
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	os.RemoveAll(s.dir)
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Askpass server stopped: %v", err)
+			}
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Printf("Error reading askpass request: %v", err)
+		return
+	}
+
+	answer, ok := s.prompt(req.Prompt)
+	resp := response{Answer: answer, Canceled: !ok}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Printf("Error writing askpass response: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package askpass
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Requested reports whether the current process was started by git or ssh
+// as an askpass helper
+func Requested() bool {
+	return os.Getenv(SocketEnv) != ""
+}
+
+// Main runs the askpass helper. It sends the prompt given in args to the
+// server, prints the answer on stdout and returns the process exit code
+func Main(args []string) int {
+	conn, err := net.Dial("unix", os.Getenv(SocketEnv))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gleam askpass: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	req := request{Prompt: strings.Join(args, " ")}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		fmt.Fprintf(os.Stderr, "gleam askpass: %v\n", err)
+		return 1
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		fmt.Fprintf(os.Stderr, "gleam askpass: %v\n", err)
+		return 1
+	}
+	if resp.Canceled {
+		return 1
+	}
+
+	fmt.Println(resp.Answer)
+	return 0
+}
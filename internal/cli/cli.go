@@ -0,0 +1,177 @@
+// Package cli runs Gleam without a window, for scripts and SSH sessions. The
+// subcommands are built on the git package, so they behave like the user
+// interface
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gleam/internal/git"
+)
+
+// Exit statuses of Main
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// subcommand is one of the commands of the command line interface
+type subcommand struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *invocation, args []string) error
+}
+
+// invocation is what a running subcommand needs
+type invocation struct {
+	flags  *flag.FlagSet
+	dir    string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var subcommands = []subcommand{
+	{"status", "[--json]", "Show the branch and the changed files", runStatus},
+	{"diff", "[--format=plain|ansi|html] [--context=N] [path...]", "Show the changes of the working tree", runDiff},
+	{"stage", "path...", "Stage files", runStage},
+	{"unstage", "path...", "Unstage files", runUnstage},
+	{"commit", "-m message [--signoff] [--summary-limit=N]", "Commit the staged changes", runCommit},
+	{"log", "[--json] [-n N] [revision]", "List commits", runLog},
+}
+
+// errUsage reports invalid arguments, whose explanation was already printed
+var errUsage = errors.New("usage")
+
+// Requested reports whether args start with a subcommand, in which case the
+// binary should run Main instead of opening a window
+func Requested(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	return args[0] == "help" || args[0] == "-h" || args[0] == "--help" ||
+		slices.ContainsFunc(subcommands, func(s subcommand) bool { return s.name == args[0] })
+}
+
+// Main runs the subcommand named by args[0] and returns the exit status
+func Main(args []string, stdout, stderr io.Writer) int {
+	index := slices.IndexFunc(subcommands, func(s subcommand) bool { return len(args) > 0 && s.name == args[0] })
+	if index < 0 {
+		printUsage(stdout)
+		if len(args) > 0 && args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	command := subcommands[index]
+	c := &invocation{stdout: stdout, stderr: stderr}
+	c.flags = flag.NewFlagSet(command.name, flag.ContinueOnError)
+	c.flags.SetOutput(stderr)
+	c.flags.StringVar(&c.dir, "C", ".", "run in `dir` instead of the current directory")
+	c.flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gleam %s %s\n\n%s\n\n", command.name, command.args, command.summary)
+		c.flags.PrintDefaults()
+	}
+
+	err := command.run(c, args[1:])
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "gleam %s: %v\n", command.name, err)
+		return exitError
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gleam [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the Gleam window opens. Commands:")
+	fmt.Fprintln(w)
+	for _, command := range subcommands {
+		fmt.Fprintf(w, "  %-8s %s\n", command.name, command.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run gleam <command> -h for the arguments of a command.")
+}
+
+// parse parses the flags of the subcommand, which may come before, after or
+// between the positional arguments, and returns the positional arguments.
+// Everything after "--" is positional
+func (c *invocation) parse(args []string) ([]string, error) {
+	var rest []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, rest = args[:i], args[i+1:]
+	}
+
+	positional := make([]string, 0)
+	for {
+		if err := c.flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		args = c.flags.Args()
+		if len(args) == 0 {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// usageError prints message and the usage of the subcommand
+func (c *invocation) usageError(message string) error {
+	fmt.Fprintf(c.stderr, "gleam %s: %s\n", c.flags.Name(), message)
+	c.flags.Usage()
+	return errUsage
+}
+
+// repository opens the repository containing the -C directory
+func (c *invocation) repository() (*git.GitCommand, error) {
+	if _, err := os.Stat(c.dir); err != nil {
+		return nil, err
+	}
+	root, err := git.NewGitCommand(c.dir).TopLevel()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a git repository", c.dir)
+	}
+	g := git.NewGitCommand(root)
+	// Scripts cannot answer credential prompts
+	g.Env = []string{"GIT_TERMINAL_PROMPT=0"}
+	return g, nil
+}
+
+// relativePaths makes paths given relative to the -C directory relative to
+// the root of the repository, which is where git runs
+func (c *invocation) relativePaths(g *git.GitCommand, paths []string) ([]string, error) {
+	prefix, err := git.NewGitCommand(c.dir).Prefix()
+	if err != nil {
+		return nil, err
+	}
+	relative := make([]string, len(paths))
+	for i, path := range paths {
+		if filepath.IsAbs(path) {
+			if path, err = filepath.Rel(g.WorkingDir, path); err != nil {
+				return nil, err
+			}
+		} else {
+			path = filepath.Join(prefix, path)
+		}
+		relative[i] = filepath.ToSlash(path)
+	}
+	return relative, nil
+}
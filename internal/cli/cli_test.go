@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepository creates a repository on branch main with one commit of
+// a.txt, isolated from the configuration of the machine
+func newRepository(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test Author")
+	t.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test Author")
+	t.Setenv("GIT_COMMITTER_EMAIL", "author@example.com")
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, dir, "a.txt", "first\n-- old comment\n")
+	runGit(t, dir, "add", "a.txt")
+	runGit(t, dir, "commit", "-q", "-m", "Initial commit")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// gleam runs the command line in dir and returns its output and exit status
+func gleam(dir string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := Main(append(args, "-C", dir), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestStatus(t *testing.T) {
+	dir := newRepository(t)
+	writeFile(t, dir, "a.txt", "first\n+++ new comment\n")
+	writeFile(t, dir, "b.txt", "new file\n")
+
+	stdout, stderr, code := gleam(dir, "status")
+	if code != exitOK {
+		t.Fatalf("status exited with %d: %s", code, stderr)
+	}
+	want := "On branch main\n M a.txt\n?? b.txt\n"
+	if stdout != want {
+		t.Errorf("status printed %q, want %q", stdout, want)
+	}
+
+	stdout, stderr, code = gleam(dir, "status", "--json")
+	if code != exitOK {
+		t.Fatalf("status --json exited with %d: %s", code, stderr)
+	}
+	var status statusOutput
+	if err := json.Unmarshal([]byte(stdout), &status); err != nil {
+		t.Fatalf("status --json printed invalid JSON: %v\n%s", err, stdout)
+	}
+	if status.Branch != "main" || len(status.Files) != 2 {
+		t.Fatalf("status --json = %+v", status)
+	}
+	if file := status.Files[0]; file.Path != "a.txt" || !file.Unstaged || file.Staged {
+		t.Errorf("status --json reports a.txt as %+v", file)
+	}
+	if file := status.Files[1]; file.Path != "b.txt" || !file.Untracked {
+		t.Errorf("status --json reports b.txt as %+v", file)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := newRepository(t)
+	writeFile(t, dir, "a.txt", "first\n+++ new comment\n")
+
+	stdout, stderr, code := gleam(dir, "diff")
+	if code != exitOK {
+		t.Fatalf("diff exited with %d: %s", code, stderr)
+	}
+	for _, line := range []string{"--- a/a.txt", "+++ b/a.txt", "--- old comment", "++++ new comment"} {
+		if !strings.Contains(stdout, line+"\n") {
+			t.Errorf("diff output lacks %q:\n%s", line, stdout)
+		}
+	}
+
+	stdout, _, code = gleam(dir, "diff", "--format=ansi", "a.txt")
+	if code != exitOK {
+		t.Fatalf("diff --format=ansi exited with %d", code)
+	}
+	for _, line := range []string{
+		ansiColors[classHeader] + "+++ b/a.txt" + ansiReset,
+		ansiColors[classRemoved] + "--- old comment" + ansiReset,
+		ansiColors[classAdded] + "++++ new comment" + ansiReset,
+	} {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("ANSI diff lacks %q:\n%q", line, stdout)
+		}
+	}
+
+	stdout, _, code = gleam(dir, "diff", "--format=html")
+	if code != exitOK {
+		t.Fatalf("diff --format=html exited with %d", code)
+	}
+	for _, span := range []string{
+		`<span class="header">--- a/a.txt</span>`,
+		`<span class="removed">--- old comment</span>`,
+		`<span class="added">++++ new comment</span>`,
+	} {
+		if !strings.Contains(stdout, span) {
+			t.Errorf("HTML diff lacks %q", span)
+		}
+	}
+
+	if _, _, code := gleam(dir, "diff", "--format=xml"); code != exitUsage {
+		t.Errorf("diff with an unknown format exited with %d, want %d", code, exitUsage)
+	}
+}
+
+func TestStageAndUnstage(t *testing.T) {
+	dir := newRepository(t)
+	writeFile(t, dir, "sub/b.txt", "new file\n")
+
+	// Paths are relative to the -C directory, like those given to git
+	if _, stderr, code := gleam(filepath.Join(dir, "sub"), "stage", "b.txt"); code != exitOK {
+		t.Fatalf("stage exited with %d: %s", code, stderr)
+	}
+	if status := runGit(t, dir, "status", "--porcelain"); status != "A  sub/b.txt\n" {
+		t.Errorf("after stage the status is %q", status)
+	}
+
+	if _, stderr, code := gleam(dir, "unstage", "sub/b.txt"); code != exitOK {
+		t.Fatalf("unstage exited with %d: %s", code, stderr)
+	}
+	if status := runGit(t, dir, "status", "--porcelain"); status != "?? sub/\n" {
+		t.Errorf("after unstage the status is %q", status)
+	}
+
+	if _, _, code := gleam(dir, "stage"); code != exitUsage {
+		t.Errorf("stage without paths exited with %d, want %d", code, exitUsage)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	dir := newRepository(t)
+	writeFile(t, dir, "b.txt", "new file\n")
+	runGit(t, dir, "add", "b.txt")
+
+	_, stderr, code := gleam(dir, "commit", "-m", "")
+	if code != exitError {
+		t.Errorf("commit with an empty summary exited with %d, want %d", code, exitError)
+	}
+	if !strings.Contains(stderr, "error: Summary is empty") {
+		t.Errorf("commit with an empty summary printed %q", stderr)
+	}
+	if count := runGit(t, dir, "rev-list", "--count", "HEAD"); count != "1\n" {
+		t.Fatalf("rejected message was committed")
+	}
+
+	if _, _, code := gleam(dir, "commit"); code != exitUsage {
+		t.Errorf("commit without a message exited with %d, want %d", code, exitUsage)
+	}
+
+	long := strings.Repeat("x", 80)
+	_, stderr, code = gleam(dir, "commit", "-m", long, "-m", "Second paragraph", "--signoff")
+	if code != exitOK {
+		t.Fatalf("commit exited with %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "warning: Summary is 80 characters, limit 72") {
+		t.Errorf("commit with a long summary printed %q", stderr)
+	}
+	message := runGit(t, dir, "log", "-1", "--format=%B")
+	want := long + "\n\nSecond paragraph\n\nSigned-off-by: Test Author <author@example.com>\n"
+	if strings.TrimRight(message, "\n") != strings.TrimRight(want, "\n") {
+		t.Errorf("committed message %q, want %q", message, want)
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir := newRepository(t)
+	writeFile(t, dir, "b.txt", "new file\n")
+	runGit(t, dir, "add", "b.txt")
+	runGit(t, dir, "commit", "-q", "-m", "Add b")
+
+	stdout, stderr, code := gleam(dir, "log")
+	if code != exitOK {
+		t.Fatalf("log exited with %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "Test Author           Add b") || !strings.HasSuffix(lines[1], "Initial commit") {
+		t.Errorf("log printed:\n%s", stdout)
+	}
+
+	stdout, _, code = gleam(dir, "log", "--json", "-n", "1")
+	if code != exitOK {
+		t.Fatalf("log --json exited with %d", code)
+	}
+	var entries []logOutput
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		t.Fatalf("log --json printed invalid JSON: %v\n%s", err, stdout)
+	}
+	head := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	if len(entries) != 1 || entries[0].Hash != head || entries[0].Subject != "Add b" || entries[0].AuthorEmail != "author@example.com" {
+		t.Errorf("log --json -n 1 = %+v", entries)
+	}
+
+	if _, _, code := gleam(dir, "log", "main", "HEAD"); code != exitUsage {
+		t.Errorf("log with two revisions exited with %d, want %d", code, exitUsage)
+	}
+	if _, _, code := gleam(dir, "log", "no-such-branch"); code != exitError {
+		t.Errorf("log of an unknown revision exited with %d, want %d", code, exitError)
+	}
+}
+
+func TestOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	_, stderr, code := gleam(dir, "status")
+	if code != exitError || !strings.Contains(stderr, "is not a git repository") {
+		t.Errorf("status outside a repository exited with %d: %s", code, stderr)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Main([]string{"frobnicate"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("unknown command exited with %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stdout.String(), "usage: gleam") {
+		t.Errorf("unknown command printed %q", stdout.String())
+	}
+	if code := Main([]string{"help"}, &stdout, &stderr); code != exitOK {
+		t.Errorf("help exited with %d, want %d", code, exitOK)
+	}
+	if code := Main([]string{"status", "-h"}, &stdout, &stderr); code != exitOK {
+		t.Errorf("status -h exited with %d, want %d", code, exitOK)
+	}
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"gleam/internal/git"
+)
+
+type statusOutput struct {
+	Branch   string       `json:"branch"`
+	Upstream string       `json:"upstream,omitempty"`
+	Ahead    int          `json:"ahead"`
+	Behind   int          `json:"behind"`
+	Files    []fileOutput `json:"files"`
+}
+
+type fileOutput struct {
+	Path      string `json:"path"`
+	OrigPath  string `json:"origPath,omitempty"`
+	Index     string `json:"index"`
+	WorkTree  string `json:"workTree"`
+	Staged    bool   `json:"staged"`
+	Unstaged  bool   `json:"unstaged"`
+	Untracked bool   `json:"untracked"`
+}
+
+type logOutput struct {
+	Hash        string    `json:"hash"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail"`
+	Date        time.Time `json:"date"`
+	Subject     string    `json:"subject"`
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func runStatus(c *invocation, args []string) error {
+	asJSON := c.flags.Bool("json", false, "print the status as JSON")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return c.usageError("status takes no arguments")
+	}
+
+	g, err := c.repository()
+	if err != nil {
+		return err
+	}
+	summary, err := g.Summary()
+	if err != nil {
+		return err
+	}
+	statuses, err := g.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	output := statusOutput{
+		Branch:   summary.Branch,
+		Upstream: summary.Upstream,
+		Ahead:    summary.Ahead,
+		Behind:   summary.Behind,
+		Files:    make([]fileOutput, len(statuses)),
+	}
+	for i, status := range statuses {
+		output.Files[i] = fileOutput{
+			Path:      status.Path,
+			OrigPath:  status.OrigPath,
+			Index:     string(status.Index),
+			WorkTree:  string(status.WorkTree),
+			Staged:    status.Staged(),
+			Unstaged:  status.Unstaged(),
+			Untracked: status.Untracked(),
+		}
+	}
+	if *asJSON {
+		return writeJSON(c.stdout, output)
+	}
+
+	fmt.Fprintf(c.stdout, "On branch %s", output.Branch)
+	if output.Upstream != "" {
+		fmt.Fprintf(c.stdout, ", tracking %s (ahead %d, behind %d)", output.Upstream, output.Ahead, output.Behind)
+	}
+	fmt.Fprintln(c.stdout)
+	for _, file := range output.Files {
+		if file.OrigPath != "" {
+			fmt.Fprintf(c.stdout, "%s%s %s -> %s\n", file.Index, file.WorkTree, file.OrigPath, file.Path)
+		} else {
+			fmt.Fprintf(c.stdout, "%s%s %s\n", file.Index, file.WorkTree, file.Path)
+		}
+	}
+	return nil
+}
+
+func runDiff(c *invocation, args []string) error {
+	format := c.flags.String("format", formatPlain, "output `format`: plain, ansi or html")
+	contextLines := c.flags.Int("context", -1, "show `n` lines around each change instead of git's default")
+	ignoreWhitespace := c.flags.Bool("ignore-whitespace", false, "ignore changes in whitespace")
+	paths, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains([]string{formatPlain, formatANSI, formatHTML}, *format) {
+		return c.usageError(fmt.Sprintf("unknown format %q", *format))
+	}
+
+	opts := git.DiffOptions{}
+	switch {
+	case *contextLines == 0:
+		opts.ContextLines = -1
+	case *contextLines > 0:
+		opts.ContextLines = *contextLines
+	}
+	if *ignoreWhitespace {
+		opts.Whitespace = git.WhitespaceIgnoreAll
+	}
+
+	g, err := c.repository()
+	if err != nil {
+		return err
+	}
+	if len(paths) > 0 {
+		if paths, err = c.relativePaths(g, paths); err != nil {
+			return err
+		}
+	} else {
+		statuses, err := g.GetStatus()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			paths = append(paths, status.Path)
+		}
+	}
+
+	diffs := make([]git.FileDiff, 0, len(paths))
+	for _, path := range paths {
+		diff, err := g.DiffFile(path, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		diffs = append(diffs, diff)
+	}
+	return writeDiffs(c.stdout, *format, diffs)
+}
+
+func runStage(c *invocation, args []string) error {
+	return changeIndex(c, args, (*git.GitCommand).Stage)
+}
+
+func runUnstage(c *invocation, args []string) error {
+	return changeIndex(c, args, (*git.GitCommand).Unstage)
+}
+
+func changeIndex(c *invocation, args []string, change func(*git.GitCommand, []string) error) error {
+	paths, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return c.usageError("no paths given")
+	}
+
+	g, err := c.repository()
+	if err != nil {
+		return err
+	}
+	if paths, err = c.relativePaths(g, paths); err != nil {
+		return err
+	}
+	return change(g, paths)
+}
+
+// messageFlag collects the paragraphs of repeated -m flags, like git commit
+type messageFlag []string
+
+func (m *messageFlag) String() string {
+	return strings.Join(*m, "\n\n")
+}
+
+func (m *messageFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
+func runCommit(c *invocation, args []string) error {
+	var message messageFlag
+	c.flags.Var(&message, "m", "use `message` as commit message, repeat for more paragraphs")
+	signOff := c.flags.Bool("signoff", false, "add a Signed-off-by trailer")
+	summaryLimit := c.flags.Int("summary-limit", git.DefaultSummaryLimit, "warn about summaries longer than `n` characters, 0 turns the warning off")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return c.usageError("commit takes no arguments, stage files first")
+	}
+	if len(message) == 0 {
+		return c.usageError("no message given")
+	}
+
+	issues := git.LintMessage(message.String(), *summaryLimit)
+	for _, issue := range issues {
+		fmt.Fprintln(c.stderr, issue)
+	}
+	if git.HasErrors(issues) {
+		return fmt.Errorf("the message was rejected")
+	}
+
+	g, err := c.repository()
+	if err != nil {
+		return err
+	}
+	return g.Commit(message.String(), git.CommitOptions{SignOff: *signOff})
+}
+
+func runLog(c *invocation, args []string) error {
+	asJSON := c.flags.Bool("json", false, "print the commits as JSON")
+	limit := c.flags.Int("n", 0, "show at most `n` commits")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return c.usageError("log takes at most one revision")
+	}
+	revision := ""
+	if len(positional) == 1 {
+		revision = positional[0]
+	}
+
+	g, err := c.repository()
+	if err != nil {
+		return err
+	}
+	entries, err := g.Log(revision, *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		output := make([]logOutput, len(entries))
+		for i, entry := range entries {
+			output[i] = logOutput{
+				Hash:        entry.Hash,
+				Author:      entry.Author,
+				AuthorEmail: entry.AuthorMail,
+				Date:        entry.Date,
+				Subject:     entry.Subject,
+			}
+		}
+		return writeJSON(c.stdout, output)
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintf(c.stdout, "%.7s  %s  %-20s  %s\n", entry.Hash, entry.Date.Format("2006-01-02 15:04"), entry.Author, entry.Subject)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"gleam/internal/git"
+	"gleam/internal/themes"
+)
+
+// Output formats of the diff subcommand
+const (
+	formatPlain = "plain"
+	formatANSI  = "ansi"
+	formatHTML  = "html"
+)
+
+// lineClass names the kind of a diff line, used as CSS class in HTML output
+type lineClass string
+
+const (
+	classHeader  lineClass = "header"
+	classHunk    lineClass = "hunk"
+	classAdded   lineClass = "added"
+	classRemoved lineClass = "removed"
+	classContext lineClass = "context"
+)
+
+var ansiColors = map[lineClass]string{
+	classHeader:  "\x1b[1m",
+	classHunk:    "\x1b[36m",
+	classAdded:   "\x1b[32m",
+	classRemoved: "\x1b[31m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// lineClasses maps the kinds of diff lines to their class
+var lineClasses = map[git.DiffLineKind]lineClass{
+	git.DiffLineHeader:  classHeader,
+	git.DiffLineHunk:    classHunk,
+	git.DiffLineAdded:   classAdded,
+	git.DiffLineRemoved: classRemoved,
+	git.DiffLineContext: classContext,
+}
+
+// classifyLines finds the class of every line of a unified diff
+func classifyLines(lines []string) []lineClass {
+	classes := make([]lineClass, len(lines))
+	for i, kind := range git.ClassifyDiffLines(lines, false) {
+		classes[i] = lineClasses[kind]
+	}
+	return classes
+}
+
+// writeDiffs writes the diffs of several files in format
+func writeDiffs(w io.Writer, format string, diffs []git.FileDiff) error {
+	if format == formatHTML {
+		return writeHTML(w, diffs)
+	}
+
+	for _, diff := range diffs {
+		if diff.Binary {
+			fmt.Fprintf(w, "Binary file %s differs (%s)\n", diff.Path, binarySizes(diff))
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSuffix(diff.Patch, "\n"), "\n")
+		if diff.Patch == "" {
+			continue
+		}
+		for i, class := range classifyLines(lines) {
+			if color, ok := ansiColors[class]; ok && format == formatANSI {
+				fmt.Fprintf(w, "%s%s%s\n", color, lines[i], ansiReset)
+			} else {
+				fmt.Fprintln(w, lines[i])
+			}
+		}
+	}
+	return nil
+}
+
+func binarySizes(diff git.FileDiff) string {
+	size := func(bytes int64) string {
+		if bytes < 0 {
+			return "none"
+		}
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+	return size(diff.OldSize) + " → " + size(diff.NewSize)
+}
+
+// writeHTML writes a standalone HTML page showing the diffs in the colors of
+// the default theme
+func writeHTML(w io.Writer, diffs []git.FileDiff) error {
+	palette := themes.Default().Palette(false)
+	css := func(c themes.Color) string {
+		return fmt.Sprintf("rgba(%d, %d, %d, %.2f)", c.R, c.G, c.B, float64(c.A)/255)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Diff</title>\n<style>\n")
+	b.WriteString("pre { font-family: monospace; margin: 0 0 1em 0; }\n")
+	b.WriteString("pre span { display: block; white-space: pre; }\n")
+	fmt.Fprintf(&b, ".header { background: %s; color: %s; font-weight: bold; }\n", css(palette.Header), css(palette.HeaderText))
+	fmt.Fprintf(&b, ".hunk { background: %s; color: %s; }\n", css(palette.Hunk), css(palette.HeaderText))
+	fmt.Fprintf(&b, ".added { background: %s; }\n", css(palette.Added))
+	fmt.Fprintf(&b, ".removed { background: %s; }\n", css(palette.Removed))
+	b.WriteString("</style>\n</head>\n<body>\n")
+
+	for _, diff := range diffs {
+		if diff.Binary {
+			fmt.Fprintf(&b, "<p class=\"binary\">Binary file %s differs (%s)</p>\n",
+				html.EscapeString(diff.Path), html.EscapeString(binarySizes(diff)))
+			continue
+		}
+		if diff.Patch == "" {
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSuffix(diff.Patch, "\n"), "\n")
+		b.WriteString("<pre>")
+		for i, class := range classifyLines(lines) {
+			fmt.Fprintf(&b, "<span class=\"%s\">%s</span>", class, html.EscapeString(lines[i]))
+		}
+		b.WriteString("</pre>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backends that can be chosen in the preferences
+const (
+	BackendExec  = "exec"
+	BackendGoGit = "go-git"
+)
+
+// Backend is the part of the git layer that the user interface polls and
+// changes most often: status, diffs of the working tree, staging, commits,
+// history and refs, together with what the file list, the diff pane and undo
+// need on every refresh and commit. GitCommand implements it by running git,
+// GoGitBackend reads the repository in process and FakeBackend keeps
+// everything in memory
+type Backend interface {
+	GetStatus() ([]FileStatus, error)
+	GetStagedFiles() ([]string, error)
+	GetUnstagedFiles() ([]string, error)
+	DiffFile(file string, opts DiffOptions) (FileDiff, error)
+	Stage(files []string) error
+	Unstage(files []string) error
+	Commit(message string, opts CommitOptions) error
+	Log(revision string, limit int) ([]LogEntry, error)
+	GetRefs() ([]Ref, error)
+	CurrentBranch() (string, error)
+
+	Submodules() ([]Submodule, error)
+	DiffSubmodule(s Submodule) SubmoduleChange
+	LFSTracked(files []string) (map[string]bool, error)
+	FileVersions(file string) ([]byte, []byte, error)
+	ResolveLFS(data []byte) []byte
+	DiffLFS(file string) (LFSChange, bool, error)
+	TakeSnapshot(opts SnapshotOptions) (Snapshot, error)
+	RestoreSnapshot(s Snapshot) error
+}
+
+var (
+	_ Backend = (*GitCommand)(nil)
+	_ Backend = (*GoGitBackend)(nil)
+	_ Backend = (*FakeBackend)(nil)
+)
+
+// OpenBackend returns the backend called name for the repository of command.
+// Operations that the backend does not cover still run through command
+func OpenBackend(name string, command *GitCommand) (Backend, error) {
+	switch name {
+	case BackendExec, "":
+		return command, nil
+	case BackendGoGit:
+		return NewGoGitBackend(command)
+	default:
+		return nil, fmt.Errorf("unknown git backend %q", name)
+	}
+}
+
+// WithContext returns b bound to ctx, so that its git processes are killed
+// when ctx is canceled. Backends that do not run processes are returned as is
+func WithContext(b Backend, ctx context.Context) Backend {
+	switch b := b.(type) {
+	case *GitCommand:
+		return b.WithContext(ctx)
+	case *GoGitBackend:
+		backend := *b
+		backend.GitCommand = b.GitCommand.WithContext(ctx)
+		return &backend
+	default:
+		return b
+	}
+}
@@ -0,0 +1,664 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fixture changes the working tree of the repository a backend is tested
+// on: files on disk for GitCommand and GoGitBackend, memory for FakeBackend
+type fixture interface {
+	write(path, content string)
+	remove(path string)
+	// git runs a git command in the repository. Only repositories on disk
+	// have one
+	git(args ...string)
+}
+
+type diskFixture struct {
+	t   *testing.T
+	dir string
+}
+
+func (f diskFixture) write(path, content string) {
+	f.t.Helper()
+	path = filepath.Join(f.dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f diskFixture) remove(path string) {
+	f.t.Helper()
+	if err := os.Remove(filepath.Join(f.dir, path)); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f diskFixture) git(args ...string) {
+	f.t.Helper()
+	runGit(f.t, f.dir, args...)
+}
+
+type fakeFixture struct {
+	t    *testing.T
+	fake *FakeBackend
+}
+
+func (f fakeFixture) write(path, content string) { f.fake.WriteFile(path, content) }
+func (f fakeFixture) remove(path string)         { f.fake.RemoveFile(path) }
+
+func (f fakeFixture) git(args ...string) {
+	f.t.Fatalf("git %s needs a repository on disk", strings.Join(args, " "))
+}
+
+// isolateGit keeps the configuration of the machine out of the tests: HOME
+// and the XDG config directory are empty temporary directories, and the
+// dates of commits are fixed so that hashes are the same for every backend
+func isolateGit(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	os.Unsetenv("GIT_CONFIG_GLOBAL")
+	t.Setenv("GIT_AUTHOR_NAME", "Test Author")
+	t.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test Committer")
+	t.Setenv("GIT_COMMITTER_EMAIL", "committer@example.com")
+	setCommitDate(t, "2024-01-01T12:00:00Z")
+}
+
+func setCommitDate(t *testing.T, date string) {
+	t.Setenv("GIT_AUTHOR_DATE", date)
+	t.Setenv("GIT_COMMITTER_DATE", date)
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+// newTestRepository creates an empty repository on branch main
+func newTestRepository(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	return dir
+}
+
+// backendUnderTest opens a backend on an empty repository on branch main
+type backendUnderTest struct {
+	name string
+	// onDisk is set for backends on a real repository, which fixtures can
+	// run git in
+	onDisk bool
+	open   func(t *testing.T) (Backend, fixture)
+}
+
+var backendsUnderTest = []backendUnderTest{
+	{BackendExec, true, func(t *testing.T) (Backend, fixture) {
+		dir := newTestRepository(t)
+		return NewGitCommand(dir), diskFixture{t, dir}
+	}},
+	{BackendGoGit, true, func(t *testing.T) (Backend, fixture) {
+		dir := newTestRepository(t)
+		backend, err := NewGoGitBackend(NewGitCommand(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return backend, diskFixture{t, dir}
+	}},
+	{"fake", false, func(t *testing.T) (Backend, fixture) {
+		fake := NewFakeBackend()
+		return fake, fakeFixture{t, fake}
+	}},
+}
+
+// backendState is everything a backend reports about a repository
+type backendState struct {
+	Status   []FileStatus
+	Staged   []string
+	Unstaged []string
+	Log      []LogEntry
+	LogErr   bool
+	Branch   string
+	Refs     []Ref
+	// Changes are the added and removed lines of the diff of each changed
+	// file. Hunk headers and context differ between backends
+	Changes map[string][]string
+	// Versions are the old and new content of each changed file, LFS the
+	// changed files stored in LFS. Both are compared with git's
+	Versions   map[string][2]string
+	LFS        map[string]bool
+	Submodules []Submodule
+}
+
+func readBackendState(t *testing.T, b Backend) backendState {
+	t.Helper()
+	var state backendState
+	var err error
+	if state.Status, err = b.GetStatus(); err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if state.Staged, err = b.GetStagedFiles(); err != nil {
+		t.Fatalf("GetStagedFiles: %v", err)
+	}
+	if state.Unstaged, err = b.GetUnstagedFiles(); err != nil {
+		t.Fatalf("GetUnstagedFiles: %v", err)
+	}
+	if state.Refs, err = b.GetRefs(); err != nil {
+		t.Fatalf("GetRefs: %v", err)
+	}
+	state.Log, err = b.Log("", 0)
+	state.LogErr = err != nil
+	// Like git, every backend fails to name the branch before the first
+	// commit
+	if branch, err := b.CurrentBranch(); err == nil {
+		state.Branch = branch
+	}
+
+	if state.Submodules, err = b.Submodules(); err != nil {
+		t.Fatalf("Submodules: %v", err)
+	}
+
+	state.Changes = make(map[string][]string)
+	state.Versions = make(map[string][2]string)
+	paths := make([]string, 0, len(state.Status))
+	for _, status := range state.Status {
+		diff, err := b.DiffFile(status.Path, DiffOptions{})
+		if err != nil {
+			t.Fatalf("DiffFile(%s): %v", status.Path, err)
+		}
+		state.Changes[status.Path] = changedPatchLines(diff.Patch)
+		oldData, newData, err := b.FileVersions(status.Path)
+		if err != nil {
+			t.Fatalf("FileVersions(%s): %v", status.Path, err)
+		}
+		state.Versions[status.Path] = [2]string{string(oldData), string(newData)}
+		paths = append(paths, status.Path)
+	}
+	if state.LFS, err = b.LFSTracked(paths); err != nil {
+		t.Fatalf("LFSTracked: %v", err)
+	}
+	return state
+}
+
+// changedPatchLines returns the added and removed lines of patch
+func changedPatchLines(patch string) []string {
+	lines := make([]string, 0)
+	inHunk := false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func subjects(entries []LogEntry) []string {
+	subjects := make([]string, len(entries))
+	for i, entry := range entries {
+		subjects[i] = entry.Subject
+	}
+	return subjects
+}
+
+func mustSucceed(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// conformanceCases describe the state every backend must report after the
+// same changes. Log is compared by subject, since the fake has its own
+// hashes and authors
+var conformanceCases = []struct {
+	name string
+	// diskOnly cases run git, so the fake cannot take part
+	diskOnly bool
+	// configure sets up the configuration of the user before the backend
+	// is opened, as it is when Gleam starts
+	configure func(t *testing.T)
+	setup     func(t *testing.T, f fixture, b Backend)
+	want      backendState
+}{
+	{
+		name:  "empty repository",
+		setup: func(t *testing.T, f fixture, b Backend) {},
+		want:  backendState{LogErr: true},
+	},
+	{
+		name: "untracked files",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("b.txt", "b\n")
+			f.write("dir/a.txt", "a\n")
+			f.write("dir/ü.txt", "unicode\n")
+		},
+		want: backendState{
+			Status: []FileStatus{
+				{Path: "b.txt", Index: '?', WorkTree: '?'},
+				{Path: "dir/a.txt", Index: '?', WorkTree: '?'},
+				{Path: "dir/ü.txt", Index: '?', WorkTree: '?'},
+			},
+			Unstaged: []string{"b.txt", "dir/a.txt", "dir/ü.txt"},
+			LogErr:   true,
+			Changes:  map[string][]string{"b.txt": {"+b"}, "dir/a.txt": {"+a"}, "dir/ü.txt": {"+unicode"}},
+		},
+	},
+	{
+		name: "staged new files",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "a\n")
+			f.write("ü.txt", "unicode\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "ü.txt"}))
+		},
+		want: backendState{
+			Status:  []FileStatus{{Path: "a.txt", Index: 'A', WorkTree: ' '}, {Path: "ü.txt", Index: 'A', WorkTree: ' '}},
+			Staged:  []string{"a.txt", "ü.txt"},
+			LogErr:  true,
+			Changes: map[string][]string{"a.txt": {"+a"}, "ü.txt": {"+unicode"}},
+		},
+	},
+	{
+		name: "modified after commit",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "one\ntwo\n")
+			f.write("b.txt", "b\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "b.txt"}))
+			mustSucceed(t, b.Commit("Add a and b\n\nWith a body", CommitOptions{}))
+			f.write("a.txt", "one\n2\n")
+		},
+		want: backendState{
+			Status:   []FileStatus{{Path: "a.txt", Index: ' ', WorkTree: 'M'}},
+			Unstaged: []string{"a.txt"},
+			Log:      []LogEntry{{Subject: "Add a and b"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"a.txt": {"-two", "+2"}},
+		},
+	},
+	{
+		name: "staged and modified",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "one\n")
+			mustSucceed(t, b.Stage([]string{"a.txt"}))
+			mustSucceed(t, b.Commit("Add a", CommitOptions{}))
+			f.write("a.txt", "two\n")
+			mustSucceed(t, b.Stage([]string{"a.txt"}))
+			f.write("a.txt", "three\n")
+		},
+		want: backendState{
+			Status:   []FileStatus{{Path: "a.txt", Index: 'M', WorkTree: 'M'}},
+			Staged:   []string{"a.txt"},
+			Unstaged: []string{"a.txt"},
+			Log:      []LogEntry{{Subject: "Add a"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"a.txt": {"-one", "+two", "-two", "+three"}},
+		},
+	},
+	{
+		name: "deleted files",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "a\n")
+			f.write("b.txt", "b\n")
+			f.write("c.txt", "c\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "b.txt", "c.txt"}))
+			mustSucceed(t, b.Commit("Add files", CommitOptions{}))
+			f.remove("a.txt")
+			f.remove("b.txt")
+			mustSucceed(t, b.Stage([]string{"b.txt"}))
+		},
+		want: backendState{
+			Status: []FileStatus{
+				{Path: "a.txt", Index: ' ', WorkTree: 'D'},
+				{Path: "b.txt", Index: 'D', WorkTree: ' '},
+			},
+			Staged:   []string{"b.txt"},
+			Unstaged: []string{"a.txt"},
+			Log:      []LogEntry{{Subject: "Add files"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"a.txt": {"-a"}, "b.txt": {"-b"}},
+		},
+	},
+	{
+		name: "unstaged again",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "a\n")
+			mustSucceed(t, b.Stage([]string{"a.txt"}))
+			mustSucceed(t, b.Commit("Add a", CommitOptions{}))
+			f.write("a.txt", "changed\n")
+			f.write("new.txt", "new\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "new.txt"}))
+			mustSucceed(t, b.Unstage([]string{"a.txt", "new.txt"}))
+		},
+		want: backendState{
+			Status: []FileStatus{
+				{Path: "a.txt", Index: ' ', WorkTree: 'M'},
+				{Path: "new.txt", Index: '?', WorkTree: '?'},
+			},
+			Unstaged: []string{"a.txt", "new.txt"},
+			Log:      []LogEntry{{Subject: "Add a"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"a.txt": {"-a", "+changed"}, "new.txt": {"+new"}},
+		},
+	},
+	{
+		name: "several commits",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			for i, subject := range []string{"First", "Second", "Third"} {
+				setCommitDate(t, "2024-01-0"+string(rune('1'+i))+"T12:00:00Z")
+				f.write("a.txt", subject+"\n")
+				mustSucceed(t, b.Stage([]string{"a.txt"}))
+				mustSucceed(t, b.Commit(subject, CommitOptions{}))
+			}
+		},
+		want: backendState{
+			Log:    []LogEntry{{Subject: "Third"}, {Subject: "Second"}, {Subject: "First"}},
+			Branch: "main",
+			Refs:   []Ref{{Name: "main", Kind: RefKindBranch}},
+		},
+	},
+	{
+		name: "restored snapshot",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "1\n")
+			mustSucceed(t, b.Stage([]string{"a.txt"}))
+			mustSucceed(t, b.Commit("First", CommitOptions{}))
+			snapshot, err := b.TakeSnapshot(SnapshotOptions{})
+			mustSucceed(t, err)
+			setCommitDate(t, "2024-01-02T12:00:00Z")
+			f.write("a.txt", "2\n")
+			f.write("b.txt", "b\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "b.txt"}))
+			mustSucceed(t, b.Commit("Second", CommitOptions{}))
+			mustSucceed(t, b.RestoreSnapshot(snapshot))
+		},
+		want: backendState{
+			Status: []FileStatus{
+				{Path: "a.txt", Index: ' ', WorkTree: 'M'},
+				{Path: "b.txt", Index: '?', WorkTree: '?'},
+			},
+			Unstaged: []string{"a.txt", "b.txt"},
+			Log:      []LogEntry{{Subject: "First"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"a.txt": {"-1", "+2"}, "b.txt": {"+b"}},
+		},
+	},
+	{
+		name: "restored snapshot with working tree",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "1\n")
+			f.write("local.txt", "committed\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "local.txt"}))
+			mustSucceed(t, b.Commit("First", CommitOptions{}))
+			snapshot, err := b.TakeSnapshot(SnapshotOptions{WorkTree: true})
+			mustSucceed(t, err)
+			setCommitDate(t, "2024-01-02T12:00:00Z")
+			f.write("a.txt", "2\n")
+			f.write("b.txt", "b\n")
+			mustSucceed(t, b.Stage([]string{"a.txt", "b.txt"}))
+			mustSucceed(t, b.Commit("Second", CommitOptions{}))
+			f.write("local.txt", "local change\n")
+			mustSucceed(t, b.RestoreSnapshot(snapshot))
+		},
+		want: backendState{
+			Status:   []FileStatus{{Path: "local.txt", Index: ' ', WorkTree: 'M'}},
+			Unstaged: []string{"local.txt"},
+			Log:      []LogEntry{{Subject: "First"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"local.txt": {"-committed", "+local change"}},
+		},
+	},
+	{
+		name: "restored files",
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "1\n")
+			mustSucceed(t, b.Stage([]string{"a.txt"}))
+			mustSucceed(t, b.Commit("First", CommitOptions{}))
+			f.write("a.txt", "2\n")
+			f.write("new.txt", "new\n")
+			snapshot, err := b.TakeSnapshot(SnapshotOptions{Files: []string{"a.txt", "new.txt", "missing.txt"}})
+			mustSucceed(t, err)
+			f.write("a.txt", "3\n")
+			f.remove("new.txt")
+			f.write("missing.txt", "created later\n")
+			mustSucceed(t, b.RestoreSnapshot(snapshot))
+		},
+		want: backendState{
+			Status: []FileStatus{
+				{Path: "a.txt", Index: ' ', WorkTree: 'M'},
+				{Path: "new.txt", Index: '?', WorkTree: '?'},
+			},
+			Unstaged: []string{"a.txt", "new.txt"},
+			Log:      []LogEntry{{Subject: "First"}},
+			Branch:   "main",
+			Refs:     []Ref{{Name: "main", Kind: RefKindBranch}},
+			Changes:  map[string][]string{"a.txt": {"-1", "+2"}, "new.txt": {"+new"}},
+		},
+	},
+	{
+		name:     "ignored files",
+		diskOnly: true,
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write(".gitignore", "*.log\n")
+			f.write("dir/.gitignore", "tmp/\n")
+			f.write("dir/tmp/cache", "cache\n")
+			f.write(".git/info/exclude", "secret.txt\n")
+			f.write("secret.txt", "secret\n")
+			f.write("build.log", "log\n")
+			f.write("keep.txt", "keep\n")
+		},
+		want: backendState{
+			Status: []FileStatus{
+				{Path: ".gitignore", Index: '?', WorkTree: '?'},
+				{Path: "dir/.gitignore", Index: '?', WorkTree: '?'},
+				{Path: "keep.txt", Index: '?', WorkTree: '?'},
+			},
+			Unstaged: []string{".gitignore", "dir/.gitignore", "keep.txt"},
+			LogErr:   true,
+			Changes:  map[string][]string{".gitignore": {"+*.log"}, "dir/.gitignore": {"+tmp/"}, "keep.txt": {"+keep"}},
+		},
+	},
+	{
+		name:     "global excludes file",
+		diskOnly: true,
+		configure: func(t *testing.T) {
+			excludes := filepath.Join(os.Getenv("HOME"), "excludes")
+			mustSucceed(t, os.WriteFile(excludes, []byte("# editor backups\n*.bak\n"), 0o644))
+			runGit(t, t.TempDir(), "config", "--global", "core.excludesFile", excludes)
+		},
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt.bak", "backup\n")
+			f.write("dir/b.txt.bak", "backup\n")
+			f.write("a.txt", "a\n")
+		},
+		want: backendState{
+			Status:   []FileStatus{{Path: "a.txt", Index: '?', WorkTree: '?'}},
+			Unstaged: []string{"a.txt"},
+			LogErr:   true,
+			Changes:  map[string][]string{"a.txt": {"+a"}},
+		},
+	},
+	{
+		name:     "default global excludes file",
+		diskOnly: true,
+		configure: func(t *testing.T) {
+			excludes := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "git", "ignore")
+			mustSucceed(t, os.MkdirAll(filepath.Dir(excludes), 0o755))
+			mustSucceed(t, os.WriteFile(excludes, []byte("*.swp\n"), 0o644))
+		},
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write(".a.txt.swp", "swap\n")
+			f.write("a.txt", "a\n")
+		},
+		want: backendState{
+			Status:   []FileStatus{{Path: "a.txt", Index: '?', WorkTree: '?'}},
+			Unstaged: []string{"a.txt"},
+			LogErr:   true,
+			Changes:  map[string][]string{"a.txt": {"+a"}},
+		},
+	},
+	{
+		name:     "merged branches",
+		diskOnly: true,
+		setup: func(t *testing.T, f fixture, b Backend) {
+			commit := func(date, file, subject string) {
+				setCommitDate(t, date)
+				f.write(file, subject+"\n")
+				f.git("add", file)
+				f.git("commit", "-q", "-m", subject)
+			}
+			commit("2024-01-01T12:00:00Z", "a.txt", "Root")
+			f.git("checkout", "-q", "-b", "topic")
+			commit("2024-01-02T12:00:00Z", "b.txt", "Topic one")
+			commit("2024-01-05T12:00:00Z", "b.txt", "Topic two")
+			f.git("checkout", "-q", "main")
+			commit("2024-01-03T12:00:00Z", "a.txt", "Main one")
+			commit("2024-01-04T12:00:00Z", "a.txt", "Main two")
+			setCommitDate(t, "2024-01-06T12:00:00Z")
+			f.git("merge", "-q", "--no-ff", "-m", "Merge topic", "topic")
+			// An author date older than the parent does not change the
+			// order, which follows the committer dates
+			t.Setenv("GIT_AUTHOR_DATE", "2023-12-01T12:00:00Z")
+			t.Setenv("GIT_COMMITTER_DATE", "2024-01-07T12:00:00Z")
+			f.write("a.txt", "rebased\n")
+			f.git("commit", "-q", "-a", "-m", "Rebased")
+			f.git("tag", "v1", "HEAD~1")
+			f.git("update-ref", "refs/remotes/origin/main", "HEAD~2")
+			f.git("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/main")
+		},
+		want: backendState{
+			Log: []LogEntry{
+				{Subject: "Rebased"},
+				{Subject: "Merge topic"},
+				{Subject: "Topic two"},
+				{Subject: "Main two"},
+				{Subject: "Main one"},
+				{Subject: "Topic one"},
+				{Subject: "Root"},
+			},
+			Branch: "main",
+			Refs: []Ref{
+				{Name: "main", Kind: RefKindBranch},
+				{Name: "topic", Kind: RefKindBranch},
+				{Name: "origin/main", Kind: RefKindRemote},
+				{Name: "v1", Kind: RefKindTag},
+			},
+		},
+	},
+	{
+		name:     "detached HEAD",
+		diskOnly: true,
+		setup: func(t *testing.T, f fixture, b Backend) {
+			f.write("a.txt", "a\n")
+			mustSucceed(t, b.Stage([]string{"a.txt"}))
+			mustSucceed(t, b.Commit("Add a", CommitOptions{}))
+			f.git("checkout", "-q", "--detach")
+		},
+		want: backendState{
+			Log:    []LogEntry{{Subject: "Add a"}},
+			Branch: "HEAD",
+			Refs:   []Ref{{Name: "main", Kind: RefKindBranch}},
+		},
+	},
+}
+
+// TestBackendConformance checks that every backend reports the same state
+// after the same changes. The backends on disk must also agree on the
+// hashes, authors and dates of the log
+func TestBackendConformance(t *testing.T) {
+	for _, tc := range conformanceCases {
+		t.Run(tc.name, func(t *testing.T) {
+			states := make(map[string]backendState)
+			for _, backend := range backendsUnderTest {
+				if tc.diskOnly && !backend.onDisk {
+					continue
+				}
+				t.Run(backend.name, func(t *testing.T) {
+					isolateGit(t)
+					if tc.configure != nil {
+						tc.configure(t)
+					}
+					b, f := backend.open(t)
+					tc.setup(t, f, b)
+					state := readBackendState(t, b)
+					states[backend.name] = state
+					checkBackendState(t, state, tc.want)
+				})
+			}
+
+			command := states[BackendExec]
+			if gogit := states[BackendGoGit]; !reflect.DeepEqual(command.Log, gogit.Log) {
+				t.Errorf("go-git log differs from git:\n%+v\n%+v", gogit.Log, command.Log)
+			}
+			for name, state := range states {
+				if !reflect.DeepEqual(state.Versions, command.Versions) {
+					t.Errorf("%s file versions %q differ from git's %q", name, state.Versions, command.Versions)
+				}
+				if !reflect.DeepEqual(state.LFS, command.LFS) {
+					t.Errorf("%s LFS files %v differ from git's %v", name, state.LFS, command.LFS)
+				}
+				if !reflect.DeepEqual(state.Submodules, command.Submodules) {
+					t.Errorf("%s submodules %+v differ from git's %+v", name, state.Submodules, command.Submodules)
+				}
+			}
+		})
+	}
+}
+
+func checkBackendState(t *testing.T, got, want backendState) {
+	t.Helper()
+	if !slices.Equal(got.Status, want.Status) && len(got.Status)+len(want.Status) > 0 {
+		t.Errorf("GetStatus() = %+v, want %+v", got.Status, want.Status)
+	}
+	if !slices.Equal(got.Staged, want.Staged) && len(got.Staged)+len(want.Staged) > 0 {
+		t.Errorf("GetStagedFiles() = %q, want %q", got.Staged, want.Staged)
+	}
+	if !slices.Equal(got.Unstaged, want.Unstaged) && len(got.Unstaged)+len(want.Unstaged) > 0 {
+		t.Errorf("GetUnstagedFiles() = %q, want %q", got.Unstaged, want.Unstaged)
+	}
+	if got.LogErr != want.LogErr {
+		t.Errorf("Log() failed: %v, want %v", got.LogErr, want.LogErr)
+	}
+	if !slices.Equal(subjects(got.Log), subjects(want.Log)) {
+		t.Errorf("Log() = %q, want %q", subjects(got.Log), subjects(want.Log))
+	}
+	if got.Branch != want.Branch {
+		t.Errorf("CurrentBranch() = %q, want %q", got.Branch, want.Branch)
+	}
+	if !slices.Equal(got.Refs, want.Refs) && len(got.Refs)+len(want.Refs) > 0 {
+		t.Errorf("GetRefs() = %+v, want %+v", got.Refs, want.Refs)
+	}
+	for path, changes := range got.Changes {
+		if !slices.Equal(changes, want.Changes[path]) {
+			t.Errorf("DiffFile(%s) changed %q, want %q", path, changes, want.Changes[path])
+		}
+	}
+}
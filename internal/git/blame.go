@@ -0,0 +1,128 @@
+package git
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine describes the commit that last changed one line of a file
+type BlameLine struct {
+	Commit     string
+	Author     string
+	AuthorMail string
+	AuthorTime time.Time
+	Summary    string
+	// Path is the name of the file in Commit, which differs from the blamed
+	// path when the file was renamed since
+	Path string
+	// PreviousCommit and PreviousPath point at the parent revision of the
+	// line, and are empty when Commit introduced the file
+	PreviousCommit string
+	PreviousPath   string
+	LineNumber     int
+	Content        string
+}
+
+// Uncommitted reports whether the line has changes that are not committed yet
+func (l BlameLine) Uncommitted() bool {
+	return strings.Trim(l.Commit, "0") == ""
+}
+
+// Blame returns the per-line authorship of file at revision, or of the
+// working tree version if revision is empty
+func (g *GitCommand) Blame(file, revision string) ([]BlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	if revision != "" {
+		args = append(args, revision)
+	}
+	args = append(args, "--", file)
+
+	output, err := g.runCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(output)
+}
+
+// parseBlame parses the output of git blame --porcelain. Commit details are
+// only printed for the first line of each commit, so they are remembered
+// and copied to every later line of the same commit
+func parseBlame(output string) ([]BlameLine, error) {
+	commits := make(map[string]*BlameLine)
+	lines := make([]BlameLine, 0)
+
+	var current *BlameLine
+	var lineNumber int
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		if strings.HasPrefix(text, "\t") {
+			if current == nil {
+				continue
+			}
+			line := *current
+			line.LineNumber = lineNumber
+			line.Content = text[1:]
+			lines = append(lines, line)
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				continue
+			}
+			number, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, err
+			}
+			lineNumber = number
+
+			info, ok := commits[fields[0]]
+			if !ok {
+				info = &BlameLine{Commit: fields[0]}
+				commits[fields[0]] = info
+			}
+			current = info
+			continue
+		}
+
+		key, value, _ := strings.Cut(text, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.AuthorMail = strings.Trim(value, "<>")
+		case "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			current.AuthorTime = time.Unix(seconds, 0)
+		case "summary":
+			current.Summary = value
+		case "filename":
+			current.Path = value
+		case "previous":
+			commit, path, _ := strings.Cut(value, " ")
+			current.PreviousCommit = commit
+			current.PreviousPath = path
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// ShowCommit returns the message and full patch of a commit
+func (g *GitCommand) ShowCommit(commit string) (string, error) {
+	return g.runCommand("show", "--format=fuller", commit)
+}
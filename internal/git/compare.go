@@ -0,0 +1,189 @@
+package git
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrMergeBaseNeedsRevision is returned for a Comparison with MergeBase set
+// whose To side is the working tree or the index
+var ErrMergeBaseNeedsRevision = errors.New("the merge base can only be used when comparing two revisions")
+
+// Comparison selects the two sides of a diff. From and To are any revisions
+// understood by git; an empty To compares against the working tree, and
+// Cached compares From (HEAD if empty) against the index instead
+type Comparison struct {
+	From   string
+	To     string
+	Cached bool
+	// MergeBase compares To against the common ancestor of From and To,
+	// which shows only the changes made on To. To must be a revision
+	MergeBase bool
+	Options   DiffOptions
+}
+
+// FileChange describes how a file differs between two revisions
+type FileChange struct {
+	Path      string
+	OldPath   string
+	Status    string
+	Additions int
+	Deletions int
+	Binary    bool
+}
+
+// Ref is a branch, remote-tracking branch or tag
+type Ref struct {
+	Name string
+	Kind string
+}
+
+// Ref kinds reported by GetRefs
+const (
+	RefKindBranch = "branch"
+	RefKindRemote = "remote"
+	RefKindTag    = "tag"
+)
+
+func (c Comparison) args() ([]string, error) {
+	if c.MergeBase && (c.To == "" || c.Cached) {
+		return nil, ErrMergeBaseNeedsRevision
+	}
+	args := append([]string{"diff"}, c.Options.Args()...)
+	args = append(args, c.Options.RenameArgs()...)
+	switch {
+	case c.Cached:
+		args = append(args, "--cached")
+		if c.From != "" {
+			args = append(args, c.From)
+		}
+	case c.MergeBase:
+		args = append(args, c.From+"..."+c.To)
+	case c.To == "":
+		args = append(args, c.From)
+	default:
+		args = append(args, c.From, c.To)
+	}
+	return args, nil
+}
+
+// ChangedFiles lists the files that differ between the two sides of c,
+// with their line statistics
+func (g *GitCommand) ChangedFiles(c Comparison) ([]FileChange, error) {
+	args, err := c.args()
+	if err != nil {
+		return nil, err
+	}
+	nameStatus, err := g.runCommand(append(args, "--name-status", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+	numstat, err := g.runCommand(append(args, "--numstat", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := parseNameStatus(nameStatus)
+	stats := parseNumstat(numstat)
+	for i := range changes {
+		if stat, ok := stats[changes[i].Path]; ok {
+			changes[i].Additions = stat.Additions
+			changes[i].Deletions = stat.Deletions
+			changes[i].Binary = stat.Binary
+		}
+	}
+	return changes, nil
+}
+
+// CompareFile returns the diff of a single changed file between the two
+// sides of c
+func (g *GitCommand) CompareFile(c Comparison, change FileChange) (string, error) {
+	args, err := c.args()
+	if err != nil {
+		return "", err
+	}
+	args = append(args, "--")
+	if change.OldPath != "" && change.OldPath != change.Path {
+		args = append(args, change.OldPath)
+	}
+	args = append(args, change.Path)
+	return g.runCommand(args...)
+}
+
+// GetRefs returns all local branches, remote-tracking branches and tags
+func (g *GitCommand) GetRefs() ([]Ref, error) {
+	output, err := g.runCommand("for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]Ref, 0)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "refs/heads/"):
+			refs = append(refs, Ref{Name: strings.TrimPrefix(line, "refs/heads/"), Kind: RefKindBranch})
+		case strings.HasPrefix(line, "refs/remotes/"):
+			if strings.HasSuffix(line, "/HEAD") {
+				continue
+			}
+			refs = append(refs, Ref{Name: strings.TrimPrefix(line, "refs/remotes/"), Kind: RefKindRemote})
+		case strings.HasPrefix(line, "refs/tags/"):
+			refs = append(refs, Ref{Name: strings.TrimPrefix(line, "refs/tags/"), Kind: RefKindTag})
+		}
+	}
+	return refs, nil
+}
+
+// parseNameStatus parses git diff --name-status -z, where renames and copies
+// are followed by both the old and the new path
+func parseNameStatus(output string) []FileChange {
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	changes := make([]FileChange, 0)
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		if status == "" || i+1 >= len(fields) {
+			continue
+		}
+
+		change := FileChange{Status: status[:1]}
+		if change.Status == "R" || change.Status == "C" {
+			if i+2 >= len(fields) {
+				break
+			}
+			change.OldPath = fields[i+1]
+			change.Path = fields[i+2]
+			i += 2
+		} else {
+			change.Path = fields[i+1]
+			i++
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// parseNumstat parses git diff --numstat -z into statistics keyed by the new
+// path. Binary files report "-" instead of line counts
+func parseNumstat(output string) map[string]FileChange {
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	stats := make(map[string]FileChange)
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+
+		path := parts[2]
+		if path == "" && i+2 < len(fields) {
+			path = fields[i+2]
+			i += 2
+		}
+
+		stat := FileChange{Path: path, Binary: parts[0] == "-"}
+		stat.Additions, _ = strconv.Atoi(parts[0])
+		stat.Deletions, _ = strconv.Atoi(parts[1])
+		stats[path] = stat
+	}
+	return stats
+}
@@ -0,0 +1,18 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMergeBaseNeedsRevision(t *testing.T) {
+	command := NewGitCommand(t.TempDir())
+	for _, c := range []Comparison{
+		{From: "main", MergeBase: true},
+		{From: "main", Cached: true, MergeBase: true},
+	} {
+		if _, err := command.ChangedFiles(c); !errors.Is(err, ErrMergeBaseNeedsRevision) {
+			t.Errorf("ChangedFiles(%+v) = %v, want ErrMergeBaseNeedsRevision", c, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DiffLineKind classifies a line of a unified diff
+type DiffLineKind int
+
+const (
+	DiffLineContext DiffLineKind = iota
+	DiffLineAdded
+	DiffLineRemoved
+	DiffLineHunk
+	DiffLineHeader
+	// DiffLineWordChange is a line of a word diff with changed words in it
+	DiffLineWordChange
+)
+
+// WordDiffPattern matches the removed and added words that git diff
+// --word-diff=plain marks as [-old-] and {+new+}
+var WordDiffPattern = regexp.MustCompile(`\[-(.*?)-\]|\{\+(.*?)\+\}`)
+
+// ClassifyDiffLines determines the kind of every line. File headers run from
+// a "diff" line to the first hunk, so removed lines that happen to start
+// with "--" are not mistaken for headers. Lines of a word diff have no
+// +/- prefix, so in one they are either context or word changes
+func ClassifyDiffLines(lines []string, wordDiff bool) []DiffLineKind {
+	kinds := make([]DiffLineKind, len(lines))
+	inHeader := false
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			inHeader = true
+			kinds[i] = DiffLineHeader
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			kinds[i] = DiffLineHunk
+		case inHeader:
+			kinds[i] = DiffLineHeader
+		case wordDiff && WordDiffPattern.MatchString(line):
+			kinds[i] = DiffLineWordChange
+		case wordDiff:
+			kinds[i] = DiffLineContext
+		case strings.HasPrefix(line, "+"):
+			kinds[i] = DiffLineAdded
+		case strings.HasPrefix(line, "-"):
+			kinds[i] = DiffLineRemoved
+		default:
+			kinds[i] = DiffLineContext
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,32 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClassifyDiffLines(t *testing.T) {
+	lines := []string{
+		"diff --git a/query.sql b/query.sql",
+		"index 1111111..2222222 100644",
+		"--- a/query.sql",
+		"+++ b/query.sql",
+		"@@ -1,3 +1,3 @@",
+		" SELECT 1;",
+		"--- removed comment",
+		"+++ added increment",
+		"-- context of a word diff",
+	}
+	want := []DiffLineKind{
+		DiffLineHeader, DiffLineHeader, DiffLineHeader, DiffLineHeader, DiffLineHunk,
+		DiffLineContext, DiffLineRemoved, DiffLineAdded, DiffLineRemoved,
+	}
+	if got := ClassifyDiffLines(lines, false); !slices.Equal(got, want) {
+		t.Errorf("ClassifyDiffLines() = %v, want %v", got, want)
+	}
+
+	want[len(want)-3], want[len(want)-2], want[len(want)-1] = DiffLineContext, DiffLineContext, DiffLineContext
+	if got := ClassifyDiffLines(lines, true); !slices.Equal(got, want) {
+		t.Errorf("ClassifyDiffLines() of a word diff = %v, want %v", got, want)
+	}
+}
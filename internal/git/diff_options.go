@@ -0,0 +1,100 @@
+package git
+
+import "fmt"
+
+// Whitespace handling modes for DiffOptions
+const (
+	WhitespaceShow         = ""
+	WhitespaceIgnoreAll    = "all"
+	WhitespaceIgnoreChange = "change"
+	WhitespaceIgnoreAtEOL  = "eol"
+)
+
+// Diff algorithms for DiffOptions
+const (
+	AlgorithmDefault   = ""
+	AlgorithmMyers     = "myers"
+	AlgorithmPatience  = "patience"
+	AlgorithmHistogram = "histogram"
+)
+
+// FullContext can be used as ContextLines to show the whole file around
+// every change
+const FullContext = 1 << 20
+
+// DiffOptions controls how diffs are computed. The zero value uses git's
+// defaults. For ContextLines and RenameThreshold, 0 keeps the default and a
+// negative value turns the feature off. Rename and copy detection only apply
+// to diffs of several files, such as comparisons of two revisions
+type DiffOptions struct {
+	Whitespace   string `json:"whitespace"`
+	ContextLines int    `json:"contextLines"`
+	Algorithm    string `json:"algorithm"`
+	// RenameThreshold and CopyThreshold are similarity percentages. Copy
+	// detection is only enabled when CopyThreshold is positive
+	RenameThreshold int  `json:"renameThreshold"`
+	CopyThreshold   int  `json:"copyThreshold"`
+	WordDiff        bool `json:"wordDiff"`
+	// MaxLines and MaxBytes, if positive, leave out the patch of a file with
+	// more changed lines or a larger version, so that huge files are not
+	// loaded unless asked for. They are not saved with the other options
+	MaxLines int   `json:"-"`
+	MaxBytes int64 `json:"-"`
+}
+
+// exceeded reports whether a diff of changed lines between versions of
+// oldSize and newSize bytes is over the limits
+func (o DiffOptions) exceeded(changed int, oldSize, newSize int64) bool {
+	return o.MaxLines > 0 && changed > o.MaxLines ||
+		o.MaxBytes > 0 && max(oldSize, newSize) > o.MaxBytes
+}
+
+// Args returns the git diff arguments for the options that apply to the
+// diff of a single path
+func (o DiffOptions) Args() []string {
+	args := make([]string, 0)
+
+	switch o.Whitespace {
+	case WhitespaceIgnoreAll:
+		args = append(args, "--ignore-all-space")
+	case WhitespaceIgnoreChange:
+		args = append(args, "--ignore-space-change")
+	case WhitespaceIgnoreAtEOL:
+		args = append(args, "--ignore-space-at-eol")
+	}
+
+	switch {
+	case o.ContextLines < 0:
+		args = append(args, "--unified=0")
+	case o.ContextLines > 0:
+		args = append(args, fmt.Sprintf("--unified=%d", o.ContextLines))
+	}
+
+	if o.Algorithm != AlgorithmDefault {
+		args = append(args, "--diff-algorithm="+o.Algorithm)
+	}
+
+	if o.WordDiff {
+		args = append(args, "--word-diff=plain")
+	}
+
+	return args
+}
+
+// RenameArgs returns the git diff arguments for rename and copy detection,
+// which pair up paths and so only matter when a diff covers several files
+func (o DiffOptions) RenameArgs() []string {
+	args := make([]string, 0, 2)
+	switch {
+	case o.RenameThreshold < 0:
+		args = append(args, "--no-renames")
+	case o.RenameThreshold > 0:
+		args = append(args, fmt.Sprintf("--find-renames=%d%%", o.RenameThreshold))
+	default:
+		args = append(args, "--find-renames")
+	}
+	if o.CopyThreshold > 0 {
+		args = append(args, fmt.Sprintf("--find-copies=%d%%", o.CopyThreshold))
+	}
+	return args
+}
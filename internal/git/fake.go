@@ -0,0 +1,546 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeBackend is a repository kept entirely in memory, for tests and
+// previews of the user interface. The working tree is changed with
+// WriteFile and RemoveFile. Diffs always show the whole file as one hunk.
+// It has no submodules and no files in LFS
+type FakeBackend struct {
+	mutex   sync.Mutex
+	branch  string
+	refs    []Ref
+	head    map[string]string
+	index   map[string]string
+	work    map[string]string
+	commits []LogEntry
+	now     func() time.Time
+
+	// trees and blobs stand in for the object database, so that snapshots
+	// can refer to file lists and contents by id. trees also holds the
+	// files of every commit under its hash, and logs its history
+	trees map[string]map[string]string
+	blobs map[string]string
+	logs  map[string][]LogEntry
+}
+
+// NewFakeBackend returns an empty repository on branch main
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		branch: "main",
+		head:   make(map[string]string),
+		index:  make(map[string]string),
+		work:   make(map[string]string),
+		now:    time.Now,
+		trees:  make(map[string]map[string]string),
+		blobs:  make(map[string]string),
+		logs:   make(map[string][]LogEntry),
+	}
+}
+
+// WriteFile sets the content of a file in the working tree
+func (f *FakeBackend) WriteFile(path, content string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.work[path] = content
+}
+
+// RemoveFile deletes a file from the working tree
+func (f *FakeBackend) RemoveFile(path string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.work, path)
+}
+
+// AddRef adds a remote-tracking branch, tag or further branch to GetRefs
+func (f *FakeBackend) AddRef(ref Ref) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.refs = append(f.refs, ref)
+}
+
+// fileCode compares two versions of a file with the status letters of git
+func fileCode(old, new map[string]string, path string) byte {
+	oldContent, inOld := old[path]
+	newContent, inNew := new[path]
+	switch {
+	case inNew && !inOld:
+		return 'A'
+	case inOld && !inNew:
+		return 'D'
+	case inOld && oldContent != newContent:
+		return 'M'
+	default:
+		return ' '
+	}
+}
+
+func (f *FakeBackend) status() []FileStatus {
+	paths := slices.Sorted(maps.Keys(f.head))
+	paths = append(paths, slices.Collect(maps.Keys(f.index))...)
+	paths = append(paths, slices.Collect(maps.Keys(f.work))...)
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	statuses := make([]FileStatus, 0)
+	for _, path := range paths {
+		_, inHead := f.head[path]
+		_, inIndex := f.index[path]
+		if !inHead && !inIndex {
+			statuses = append(statuses, FileStatus{Path: path, Index: '?', WorkTree: '?'})
+			continue
+		}
+
+		status := FileStatus{Path: path, Index: fileCode(f.head, f.index, path), WorkTree: fileCode(f.index, f.work, path)}
+		if status.WorkTree == 'A' {
+			status.WorkTree = ' '
+		}
+		if status.Index != ' ' || status.WorkTree != ' ' {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// GetStatus returns the status of every changed, staged or untracked file
+func (f *FakeBackend) GetStatus() ([]FileStatus, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.status(), nil
+}
+
+// GetStagedFiles returns the files with changes in the index
+func (f *FakeBackend) GetStagedFiles() ([]string, error) {
+	return f.filesWhere(FileStatus.Staged)
+}
+
+// GetUnstagedFiles returns the modified, deleted and untracked files of the
+// working tree
+func (f *FakeBackend) GetUnstagedFiles() ([]string, error) {
+	return f.filesWhere(func(status FileStatus) bool {
+		return status.Unstaged() || status.Untracked()
+	})
+}
+
+func (f *FakeBackend) filesWhere(accept func(FileStatus) bool) ([]string, error) {
+	statuses, _ := f.GetStatus()
+	files := make([]string, 0)
+	for _, status := range statuses {
+		if accept(status) {
+			files = append(files, status.Path)
+		}
+	}
+	return files, nil
+}
+
+// DiffFile compares file against the same base as GitCommand.DiffFile.
+// Apart from the size limits, the options are ignored
+func (f *FakeBackend) DiffFile(file string, opts DiffOptions) (FileDiff, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	sources := f.sources(file)
+	diff := FileDiff{
+		Path:    file,
+		OldSize: fakeSize(sources[0][0], file),
+		NewSize: fakeSize(sources[len(sources)-1][1], file),
+	}
+	for _, source := range sources {
+		oldContent, inOld := source[0][file]
+		newContent, inNew := source[1][file]
+		if strings.Contains(oldContent, "\x00") || strings.Contains(newContent, "\x00") {
+			diff.Binary = true
+			diff.Patch = ""
+			return diff, nil
+		}
+		if inOld || inNew {
+			diff.Patch += unifiedDiff(file, oldContent, newContent, inOld, inNew)
+		}
+	}
+	if opts.exceeded(changedLines(diff.Patch), diff.OldSize, diff.NewSize) {
+		diff.Patch = ""
+		diff.TooLarge = true
+	}
+	return diff, nil
+}
+
+// sources returns the old and the new side of each diff that DiffFile shows
+// for file
+func (f *FakeBackend) sources(file string) [][2]map[string]string {
+	var status FileStatus
+	statuses := f.status()
+	if i := slices.IndexFunc(statuses, func(s FileStatus) bool { return s.Path == file }); i >= 0 {
+		status = statuses[i]
+	}
+
+	switch {
+	case status.Untracked():
+		return [][2]map[string]string{{nil, f.work}}
+	case status.Staged() && status.Unstaged():
+		return [][2]map[string]string{{f.head, f.index}, {f.index, f.work}}
+	case status.Staged():
+		return [][2]map[string]string{{f.head, f.index}}
+	default:
+		return [][2]map[string]string{{f.index, f.work}}
+	}
+}
+
+// FileVersions returns the old and new content of file, taken from the same
+// sides that DiffFile compares. A side that does not exist is returned as nil
+func (f *FakeBackend) FileVersions(file string) ([]byte, []byte, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	sources := f.sources(file)
+	return fakeContent(sources[0][0], file), fakeContent(sources[len(sources)-1][1], file), nil
+}
+
+func fakeContent(files map[string]string, path string) []byte {
+	content, ok := files[path]
+	if !ok {
+		return nil
+	}
+	return []byte(content)
+}
+
+// Submodules returns no submodules
+func (f *FakeBackend) Submodules() ([]Submodule, error) {
+	return make([]Submodule, 0), nil
+}
+
+// DiffSubmodule reports the recorded commit of s as unchanged
+func (f *FakeBackend) DiffSubmodule(s Submodule) SubmoduleChange {
+	return SubmoduleChange{Submodule: s, Old: s.Recorded, New: s.Recorded}
+}
+
+// LFSTracked reports that none of files are stored in LFS, since the fake
+// has no attributes
+func (f *FakeBackend) LFSTracked(files []string) (map[string]bool, error) {
+	return make(map[string]bool), nil
+}
+
+// ResolveLFS returns data unchanged, since the fake has no LFS store
+func (f *FakeBackend) ResolveLFS(data []byte) []byte {
+	return data
+}
+
+// DiffLFS compares the versions of file like GitCommand.DiffLFS. The fake
+// has no LFS store, so the objects of pointers are never local
+func (f *FakeBackend) DiffLFS(file string) (LFSChange, bool, error) {
+	oldData, newData, err := f.FileVersions(file)
+	if err != nil {
+		return LFSChange{}, false, err
+	}
+	change, ok := compareLFS(file, oldData, newData, func(LFSPointer) bool { return false })
+	return change, ok, nil
+}
+
+// changedLines counts the added and removed lines of the hunks of patch
+func changedLines(patch string) int {
+	changed, inHunk := 0, false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			changed++
+		}
+	}
+	return changed
+}
+
+func fakeSize(files map[string]string, path string) int64 {
+	content, ok := files[path]
+	if !ok {
+		return -1
+	}
+	return int64(len(content))
+}
+
+// unifiedDiff returns a diff of two versions of a file with a single hunk
+// covering the whole file
+func unifiedDiff(path, oldContent, newContent string, oldExists, newExists bool) string {
+	if oldContent == newContent && oldExists == newExists {
+		return ""
+	}
+	oldLines, newLines := splitLines(oldContent), splitLines(newContent)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+	switch {
+	case !oldExists:
+		fmt.Fprintf(&b, "new file mode 100644\n--- /dev/null\n+++ b/%s\n", path)
+	case !newExists:
+		fmt.Fprintf(&b, "deleted file mode 100644\n--- a/%s\n+++ /dev/null\n", path)
+	default:
+		fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	}
+	fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(len(oldLines)), hunkRange(len(newLines)))
+
+	// Longest common subsequence of the lines, filled from the end
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			b.WriteString(" " + oldLines[i] + "\n")
+			i, j = i+1, j+1
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString("-" + oldLines[i] + "\n")
+			i++
+		default:
+			b.WriteString("+" + newLines[j] + "\n")
+			j++
+		}
+	}
+	return b.String()
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+func hunkRange(lines int) string {
+	switch lines {
+	case 0:
+		return "0,0"
+	case 1:
+		return "1"
+	default:
+		return fmt.Sprintf("1,%d", lines)
+	}
+}
+
+// Stage copies files from the working tree to the index
+func (f *FakeBackend) Stage(files []string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.copyFiles(files, f.work, f.index)
+}
+
+// Unstage resets files in the index to their committed version
+func (f *FakeBackend) Unstage(files []string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.copyFiles(files, f.head, f.index)
+}
+
+func (f *FakeBackend) copyFiles(files []string, from, to map[string]string) error {
+	for _, file := range files {
+		_, inHead := f.head[file]
+		_, inIndex := f.index[file]
+		_, inWork := f.work[file]
+		if !inHead && !inIndex && !inWork {
+			return fmt.Errorf("pathspec %q did not match any files", file)
+		}
+	}
+	for _, file := range files {
+		if content, ok := from[file]; ok {
+			to[file] = content
+		} else {
+			delete(to, file)
+		}
+	}
+	return nil
+}
+
+// Commit records the index as a new commit on the current branch
+func (f *FakeBackend) Commit(message string, opts CommitOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if maps.Equal(f.head, f.index) {
+		return errors.New("nothing to commit")
+	}
+
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%d\x00%s", len(f.commits), message)
+	for _, path := range slices.Sorted(maps.Keys(f.index)) {
+		fmt.Fprintf(hash, "\x00%s\x00%s", path, f.index[path])
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+
+	commit := hex.EncodeToString(hash.Sum(nil))
+	f.commits = slices.Insert(f.commits, 0, LogEntry{
+		Hash:       commit,
+		Author:     "Gleam",
+		AuthorMail: "gleam@example.com",
+		Date:       f.now().Truncate(time.Second),
+		Subject:    strings.TrimSpace(subject),
+	})
+	f.head = maps.Clone(f.index)
+	f.trees[commit] = maps.Clone(f.index)
+	f.logs[commit] = slices.Clone(f.commits)
+	return nil
+}
+
+// Log returns the commits reachable from revision, which is HEAD, the
+// current branch or a commit hash
+func (f *FakeBackend) Log(revision string, limit int) ([]LogEntry, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	start := 0
+	switch {
+	case revision == "" || revision == "HEAD" || revision == f.branch:
+	default:
+		start = slices.IndexFunc(f.commits, func(entry LogEntry) bool {
+			return len(revision) >= 4 && strings.HasPrefix(entry.Hash, revision)
+		})
+	}
+	if start < 0 || len(f.commits) == 0 {
+		return nil, fmt.Errorf("unknown revision %q", revision)
+	}
+
+	entries := slices.Clone(f.commits[start:])
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// GetRefs returns the current branch and the refs added with AddRef. Like
+// git, the branch is only listed once it has a commit
+func (f *FakeBackend) GetRefs() ([]Ref, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	refs := make([]Ref, 0, len(f.refs)+1)
+	if len(f.commits) > 0 {
+		refs = append(refs, Ref{Name: f.branch, Kind: RefKindBranch})
+	}
+	return append(refs, f.refs...), nil
+}
+
+// CurrentBranch returns the name of the checked out branch. Like git, it
+// fails before the first commit
+func (f *FakeBackend) CurrentBranch() (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.commits) == 0 {
+		return "", fmt.Errorf("branch %s has no commits yet", f.branch)
+	}
+	return f.branch, nil
+}
+
+// fakeHash returns an id for the contents of an object
+func fakeHash(parts ...string) string {
+	hash := sha1.New()
+	for _, part := range parts {
+		fmt.Fprintf(hash, "%s\x00", part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// TakeSnapshot records the current state of the repository. The index and
+// the saved files are kept under ids like the trees and blobs of git
+func (f *FakeBackend) TakeSnapshot(opts SnapshotOptions) (Snapshot, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	snapshot := Snapshot{
+		Branch:   f.branch,
+		Refs:     make(map[string]string),
+		Files:    make(map[string]SavedFile),
+		WorkTree: opts.WorkTree,
+	}
+	if len(f.commits) > 0 {
+		snapshot.Head = f.commits[0].Hash
+		snapshot.Refs["refs/heads/"+f.branch] = snapshot.Head
+	}
+
+	parts := make([]string, 0, 2*len(f.index))
+	for _, path := range slices.Sorted(maps.Keys(f.index)) {
+		parts = append(parts, path, f.index[path])
+	}
+	snapshot.Index = fakeHash(parts...)
+	f.trees[snapshot.Index] = maps.Clone(f.index)
+
+	for _, file := range opts.Files {
+		content, ok := f.work[file]
+		if !ok {
+			snapshot.Files[file] = SavedFile{}
+			continue
+		}
+		blob := fakeHash(content)
+		f.blobs[blob] = content
+		snapshot.Files[file] = SavedFile{Blob: blob, Mode: "100644"}
+	}
+	return snapshot, nil
+}
+
+// RestoreSnapshot puts the repository back into the state of s. When s
+// updated the working tree, files without local changes are checked out from
+// the restored commit, like reset --keep does
+func (f *FakeBackend) RestoreSnapshot(s Snapshot) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	head, commits := make(map[string]string), []LogEntry(nil)
+	if s.Head != "" {
+		tree, ok := f.trees[s.Head]
+		if !ok {
+			return fmt.Errorf("unknown commit %s", s.Head)
+		}
+		head, commits = maps.Clone(tree), slices.Clone(f.logs[s.Head])
+	}
+
+	if s.WorkTree {
+		paths := slices.Concat(slices.Collect(maps.Keys(f.head)), slices.Collect(maps.Keys(head)))
+		for _, path := range paths {
+			current, inCurrent := f.head[path]
+			work, inWork := f.work[path]
+			if inCurrent != inWork || current != work {
+				continue
+			}
+			if content, ok := head[path]; ok {
+				f.work[path] = content
+			} else {
+				delete(f.work, path)
+			}
+		}
+	}
+	f.head, f.commits = head, commits
+
+	if s.Index != "" {
+		tree, ok := f.trees[s.Index]
+		if !ok {
+			return fmt.Errorf("unknown tree %s", s.Index)
+		}
+		f.index = maps.Clone(tree)
+	}
+	for file, saved := range s.Files {
+		if saved.Blob == "" {
+			delete(f.work, file)
+		} else {
+			f.work[file] = f.blobs[saved.Blob]
+		}
+	}
+	return nil
+}
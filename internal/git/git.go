@@ -3,32 +3,151 @@ package git
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"time"
 )
 
 // GitCommand represents a Git command executor with a working directory
 type GitCommand struct {
 	WorkingDir string
+	// Binary is the git executable to run. It defaults to "git" on the PATH
+	Binary string
+	// Env holds extra environment variables, such as askpass settings, that
+	// are added to every git invocation
+	Env []string
+	// Observer, if set, is called after every git invocation finished. It is
+	// called on the goroutine that ran git
+	Observer func(Invocation)
+	ctx      context.Context
+	// lfsFiles is shared by the copies WithContext makes
+	lfsFiles *lfsFilesCache
+}
+
+// Invocation records a finished run of git
+type Invocation struct {
+	Args     []string
+	Dir      string
+	Start    time.Time
+	Duration time.Duration
+	// ExitCode is -1 if git could not be started or was killed
+	ExitCode    int
+	StdoutBytes int
+	Stderr      string
+	Err         error
 }
 
 // NewGitCommand creates a new GitCommand instance with the specified working directory
 func NewGitCommand(workingDir string) *GitCommand {
-	return &GitCommand{WorkingDir: workingDir}
+	return &GitCommand{WorkingDir: workingDir, lfsFiles: &lfsFilesCache{}}
+}
+
+// WithContext returns a copy of the command whose git processes are killed
+// when ctx is canceled
+func (g *GitCommand) WithContext(ctx context.Context) *GitCommand {
+	command := *g
+	command.ctx = ctx
+	return &command
 }
 
 // runCommand executes a git command with the given arguments and returns its output
 func (g *GitCommand) runCommand(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
+	output, err := g.execute(args...)
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// runDiffCommand executes a git diff command. Diffs run with --no-index exit
+// with status 1 when the files differ, which is not treated as an error
+func (g *GitCommand) runDiffCommand(args ...string) (string, error) {
+	output, err := g.execute(args...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return output, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// execute runs git and returns its output even if the command failed
+func (g *GitCommand) execute(args ...string) (string, error) {
+	stdout, _, err := g.Run(args...)
+	return stdout, err
+}
+
+// Run runs git with arbitrary arguments, such as a command typed by the
+// user, and returns what it wrote to stdout and stderr
+func (g *GitCommand) Run(args ...string) (string, string, error) {
+	ctx := g.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	binary := g.Binary
+	if binary == "" {
+		binary = "git"
+	}
+
+	cmd := exec.CommandContext(ctx, binary, args...)
 	cmd.Dir = g.WorkingDir
+	if len(g.Env) > 0 {
+		cmd.Env = append(os.Environ(), g.Env...)
+	}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	start := time.Now()
 	err := cmd.Run()
+
+	if g.Observer != nil {
+		exitCode := 0
+		if err != nil {
+			exitCode = -1
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		g.Observer(Invocation{
+			Args:        args,
+			Dir:         g.WorkingDir,
+			Start:       start,
+			Duration:    time.Since(start),
+			ExitCode:    exitCode,
+			StdoutBytes: stdout.Len(),
+			Stderr:      stderr.String(),
+			Err:         err,
+		})
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// TopLevel returns the root of the work tree that contains WorkingDir
+func (g *GitCommand) TopLevel() (string, error) {
+	output, err := g.runCommand("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Prefix returns the path of WorkingDir relative to the root of the work
+// tree, with a trailing slash, or an empty string at the root
+func (g *GitCommand) Prefix() (string, error) {
+	output, err := g.runCommand("rev-parse", "--show-prefix")
 	if err != nil {
 		return "", err
 	}
-	return out.String(), nil
+	return strings.TrimSpace(output), nil
 }
 
 // GetDiff returns the diff of all changes in the working directory
@@ -37,46 +156,58 @@ func (g *GitCommand) GetDiff() (string, error) {
 }
 
 // GetFileDiff returns the diff for a specific file
-func (g *GitCommand) GetFileDiff(file string) (string, error) {
-	return g.runCommand("diff", file)
+func (g *GitCommand) GetFileDiff(file string, opts DiffOptions) (string, error) {
+	args := append([]string{"diff"}, opts.Args()...)
+	args = append(args, "--", file)
+	return g.runCommand(args...)
 }
 
 // GetStagedFiles returns a list of files that are staged for commit
 func (g *GitCommand) GetStagedFiles() ([]string, error) {
-	output, err := g.runCommand("--name-only", "--cached")
+	output, err := g.runCommand("diff", "--name-only", "-z", "--cached")
 	if output == "" {
 		return []string{}, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	files := strings.Split(strings.TrimSpace(output), "\n")
-	if len(files) == 1 && files[0] == "" {
-		return []string{}, nil
-	}
-
-	return files, nil
+	return splitPaths(output), nil
 }
 
-// GetUnstagedFiles returns a list of files that have changes but are not staged
+// GetUnstagedFiles returns a list of files that have changes but are not
+// staged, sorted by path
 func (g *GitCommand) GetUnstagedFiles() ([]string, error) {
-	output, err := g.runCommand("ls-files", "--others", "--modified", "--exclude-standard")
+	output, err := g.runCommand("ls-files", "-z", "--others", "--modified", "--exclude-standard")
 	if err != nil {
 		return nil, err
 	}
 
-	files := strings.Split(strings.TrimSpace(output), "\n")
-	if len(files) == 1 && files[0] == "" {
-		return []string{}, nil
-	}
+	// Untracked files are listed before modified ones
+	files := splitPaths(output)
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// splitPaths splits the NUL-terminated paths printed by -z, which git does
+// not quote
+func splitPaths(output string) []string {
+	files := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	return slices.DeleteFunc(files, func(file string) bool { return file == "" })
+}
 
-	return files, nil
+// CommitOptions controls how Commit records a commit
+type CommitOptions struct {
+	// SignOff adds a Signed-off-by trailer for the committer
+	SignOff bool
 }
 
 // Commit creates a new commit with the given message
-func (g *GitCommand) Commit(message string) error {
-	_, err := g.runCommand("commit", "-m", message)
+func (g *GitCommand) Commit(message string, opts CommitOptions) error {
+	args := []string{"commit", "-m", message}
+	if opts.SignOff {
+		args = append(args, "--signoff")
+	}
+	_, err := g.runCommand(args...)
 	return err
 }
 
@@ -94,22 +225,45 @@ func (g *GitCommand) Reset(files []string) error {
 	return err
 }
 
-// Push pushes commits to the remote repository
-func (g *GitCommand) Push() error {
-	_, err := g.runCommand("push")
-	return err
+// Push pushes commits to the remote repository. Without an explicit remote it
+// returns ErrNoUpstream if the current branch does not track a remote branch
+func (g *GitCommand) Push(opts PushOptions) error {
+	if opts.Branch != "" && opts.Remote == "" && !opts.SetUpstream {
+		return ErrBranchNeedsRemote
+	}
+	if opts.SetUpstream {
+		if opts.Remote == "" {
+			remote, err := g.DefaultRemote()
+			if err != nil {
+				return err
+			}
+			opts.Remote = remote
+		}
+		if opts.Branch == "" {
+			branch, err := g.CurrentBranch()
+			if err != nil {
+				return err
+			}
+			if branch == "HEAD" {
+				return ErrDetachedHead
+			}
+			opts.Branch = branch
+		}
+	} else if opts.Remote == "" && !g.HasUpstream() {
+		return ErrNoUpstream
+	}
+
+	return g.runRemoteCommand(opts.args()...)
 }
 
-// Pull fetches and merges changes from the remote repository
-func (g *GitCommand) Pull() error {
-	_, err := g.runCommand("pull")
-	return err
+// Pull fetches and integrates changes from the remote repository
+func (g *GitCommand) Pull(opts PullOptions) error {
+	return g.runRemoteCommand(opts.args()...)
 }
 
 // Fetch downloads objects and refs from the remote repository
-func (g *GitCommand) Fetch() error {
-	_, err := g.runCommand("fetch")
-	return err
+func (g *GitCommand) Fetch(opts FetchOptions) error {
+	return g.runRemoteCommand(opts.args()...)
 }
 
 // Stage adds the specified files to the staging area
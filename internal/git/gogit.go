@@ -0,0 +1,232 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// GoGitBackend reads status, history and refs with go-git instead of
+// starting a git process for every poll. Diffs and everything that writes
+// to the repository still run git through the embedded GitCommand
+type GoGitBackend struct {
+	*GitCommand
+	repo *gogit.Repository
+	// excludesFile holds the ignore patterns of every repository of the
+	// user, which go-git does not read itself
+	excludesFile string
+}
+
+// NewGoGitBackend opens the repository of command with go-git
+func NewGoGitBackend(command *GitCommand) (*GoGitBackend, error) {
+	repo, err := gogit.PlainOpenWithOptions(command.WorkingDir, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &GoGitBackend{GitCommand: command, repo: repo, excludesFile: command.excludesFile()}, nil
+}
+
+// excludesFile returns the path of core.excludesFile, or of git/ignore in
+// the XDG config directory that git reads when it is not set
+func (g *GitCommand) excludesFile() string {
+	if output, err := g.runCommand("config", "--path", "--get", "core.excludesFile"); err == nil {
+		if path := strings.TrimSpace(output); path != "" {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(g.WorkingDir, path)
+			}
+			return path
+		}
+	}
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		config = filepath.Join(home, ".config")
+	}
+	return filepath.Join(config, "git", "ignore")
+}
+
+// globalExcludes reads the patterns of the excludes file. A missing file has
+// none
+func (b *GoGitBackend) globalExcludes() ([]gitignore.Pattern, error) {
+	if b.excludesFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(b.excludesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]gitignore.Pattern, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return patterns, nil
+}
+
+// GetStatus returns the status of every changed, staged or untracked file,
+// sorted by path like git status
+func (b *GoGitBackend) GetStatus() ([]FileStatus, error) {
+	worktree, err := b.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := b.globalExcludes()
+	if err != nil {
+		return nil, err
+	}
+	worktree.Excludes = append(worktree.Excludes, excludes...)
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]FileStatus, 0, len(status))
+	for path, file := range status {
+		if file.Staging == gogit.Unmodified && file.Worktree == gogit.Unmodified {
+			continue
+		}
+		statuses = append(statuses, FileStatus{
+			Path:     path,
+			OrigPath: file.Extra,
+			Index:    byte(file.Staging),
+			WorkTree: byte(file.Worktree),
+		})
+	}
+	slices.SortFunc(statuses, func(a, b FileStatus) int { return strings.Compare(a.Path, b.Path) })
+	return statuses, nil
+}
+
+// GetStagedFiles returns the files with changes in the index
+func (b *GoGitBackend) GetStagedFiles() ([]string, error) {
+	return b.filesWhere(FileStatus.Staged)
+}
+
+// GetUnstagedFiles returns the modified, deleted and untracked files of the
+// working tree
+func (b *GoGitBackend) GetUnstagedFiles() ([]string, error) {
+	return b.filesWhere(func(status FileStatus) bool {
+		return status.Unstaged() || status.Untracked()
+	})
+}
+
+func (b *GoGitBackend) filesWhere(accept func(FileStatus) bool) ([]string, error) {
+	statuses, err := b.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, status := range statuses {
+		if accept(status) {
+			files = append(files, status.Path)
+		}
+	}
+	return files, nil
+}
+
+// Log returns the commits reachable from revision, newest first. It returns
+// at most limit entries unless limit is 0, and revision defaults to HEAD
+func (b *GoGitBackend) Log(revision string, limit int) ([]LogEntry, error) {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	hash, err := b.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, err
+	}
+	commits, err := b.repo.Log(&gogit.LogOptions{From: *hash, Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+
+	entries := make([]LogEntry, 0)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if limit > 0 && len(entries) == limit {
+			return storer.ErrStop
+		}
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		entries = append(entries, LogEntry{
+			Hash:       commit.Hash.String(),
+			Author:     commit.Author.Name,
+			AuthorMail: commit.Author.Email,
+			Date:       time.Unix(commit.Author.When.Unix(), 0),
+			Subject:    strings.TrimSpace(subject),
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// GetRefs returns all local branches, remote-tracking branches and tags
+func (b *GoGitBackend) GetRefs() ([]Ref, error) {
+	iter, err := b.repo.References()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	names := make([]plumbing.ReferenceName, 0)
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		if name.IsBranch() || name.IsTag() || (name.IsRemote() && !strings.HasSuffix(name.String(), "/HEAD")) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+
+	refs := make([]Ref, len(names))
+	for i, name := range names {
+		switch {
+		case name.IsBranch():
+			refs[i] = Ref{Name: strings.TrimPrefix(name.String(), "refs/heads/"), Kind: RefKindBranch}
+		case name.IsRemote():
+			refs[i] = Ref{Name: strings.TrimPrefix(name.String(), "refs/remotes/"), Kind: RefKindRemote}
+		default:
+			refs[i] = Ref{Name: strings.TrimPrefix(name.String(), "refs/tags/"), Kind: RefKindTag}
+		}
+	}
+	return refs, nil
+}
+
+// CurrentBranch returns the name of the checked out branch, or HEAD when it
+// is detached
+func (b *GoGitBackend) CurrentBranch() (string, error) {
+	head, err := b.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() == plumbing.SymbolicReference {
+		// Like git, an unborn branch has no name yet
+		if _, err := b.repo.Reference(head.Target(), true); err != nil {
+			return "", err
+		}
+		return head.Target().Short(), nil
+	}
+	return "HEAD", nil
+}
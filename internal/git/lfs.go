@@ -0,0 +1,407 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// lfsSpec is the version line that starts every LFS pointer file
+	lfsSpec = "version https://git-lfs.github.com/spec/v1"
+	// lfsPointerMaxSize is the size git LFS allows pointer files to have
+	lfsPointerMaxSize = 1024
+	// lfsAttributes are the attributes git lfs track writes for a pattern
+	lfsAttributes = "filter=lfs diff=lfs merge=lfs -text"
+)
+
+// LFSPointer is the text that git LFS commits in place of the content of a
+// file, which it keeps in a separate object store
+type LFSPointer struct {
+	// OID is the SHA-256 of the content, in hex
+	OID  string
+	Size int64
+}
+
+// ParseLFSPointer reads an LFS pointer file. It reports false if data is
+// not one
+func ParseLFSPointer(data []byte) (LFSPointer, bool) {
+	if len(data) > lfsPointerMaxSize || !bytes.HasPrefix(data, []byte(lfsSpec+"\n")) {
+		return LFSPointer{}, false
+	}
+
+	var pointer LFSPointer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "oid":
+			pointer.OID = strings.TrimPrefix(value, "sha256:")
+		case "size":
+			pointer.Size, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return pointer, pointer.OID != ""
+}
+
+// LFSPointerFor returns the pointer of data, which is either a pointer file
+// or content that git LFS would replace by one
+func LFSPointerFor(data []byte) LFSPointer {
+	if pointer, ok := ParseLFSPointer(data); ok {
+		return pointer
+	}
+	sum := sha256.Sum256(data)
+	return LFSPointer{OID: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+}
+
+// HasLFSPointer reports whether text, such as a patch, contains a pointer
+// file
+func HasLFSPointer(text string) bool {
+	return strings.Contains(text, lfsSpec)
+}
+
+// LFSTracked returns which of files are stored in LFS, either because their
+// attributes send them through the LFS filter or because git lfs ls-files
+// lists them
+func (g *GitCommand) LFSTracked(files []string) (map[string]bool, error) {
+	tracked := make(map[string]bool)
+	if len(files) == 0 {
+		return tracked, nil
+	}
+
+	output, err := g.runCommand(append([]string{"check-attr", "-z", "filter", "--"}, files...)...)
+	if err != nil {
+		return nil, err
+	}
+	// Each attribute is reported as path, attribute and value
+	fields := strings.Split(output, "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		if fields[i+2] == "lfs" {
+			tracked[fields[i]] = true
+		}
+	}
+
+	// Without git-lfs the attributes are all there is to go by
+	if listed, err := g.lfsListedFiles(); err == nil {
+		for _, file := range listed {
+			if slices.Contains(files, file) {
+				tracked[file] = true
+			}
+		}
+	}
+	return tracked, nil
+}
+
+// LFSFiles lists the tracked files stored in LFS. Without git-lfs installed
+// they are found by their attributes
+func (g *GitCommand) LFSFiles() ([]string, error) {
+	if files, err := g.lfsListedFiles(); err == nil {
+		return files, nil
+	}
+
+	output, err := g.runCommand("ls-files", "-z", "--", ":(attr:filter=lfs)")
+	if err != nil {
+		return nil, err
+	}
+	return splitPaths(output), nil
+}
+
+// lfsFilesCache holds what git lfs ls-files listed, which takes long in big
+// repositories, for the state of HEAD and the index it was listed in
+type lfsFilesCache struct {
+	sync.Mutex
+	key   string
+	files []string
+}
+
+// lfsListedFiles returns the files git lfs ls-files lists. The list is
+// reused until HEAD or the index change
+func (g *GitCommand) lfsListedFiles() ([]string, error) {
+	key := g.lfsFilesKey()
+	if key != "" && g.lfsFiles != nil {
+		g.lfsFiles.Lock()
+		hit, files := g.lfsFiles.key == key, g.lfsFiles.files
+		g.lfsFiles.Unlock()
+		if hit {
+			return slices.Clone(files), nil
+		}
+	}
+
+	output, err := g.runCommand("lfs", "ls-files", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+	files := slices.DeleteFunc(strings.Split(strings.TrimSpace(output), "\n"), func(file string) bool { return file == "" })
+	if key != "" && g.lfsFiles != nil {
+		g.lfsFiles.Lock()
+		g.lfsFiles.key, g.lfsFiles.files = key, slices.Clone(files)
+		g.lfsFiles.Unlock()
+	}
+	return files, nil
+}
+
+// lfsFilesKey identifies the commit of HEAD and the version of the index.
+// It is empty when either is missing, so nothing is cached
+func (g *GitCommand) lfsFilesKey() string {
+	output, err := g.runCommand("rev-parse", "--git-path", "index", "HEAD")
+	if err != nil {
+		return ""
+	}
+	index, head, ok := strings.Cut(strings.TrimSpace(output), "\n")
+	if !ok {
+		return ""
+	}
+	if !filepath.IsAbs(index) {
+		index = filepath.Join(g.WorkingDir, index)
+	}
+	info, err := os.Stat(index)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %d %d", head, info.ModTime().UnixNano(), info.Size())
+}
+
+// lfsObjectPath returns where the local LFS store keeps the object of oid
+func (g *GitCommand) lfsObjectPath(oid string) (string, error) {
+	if len(oid) < 5 {
+		return "", errors.New("invalid LFS object ID " + oid)
+	}
+	output, err := g.runCommand("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(output)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.WorkingDir, dir)
+	}
+	return filepath.Join(dir, "lfs", "objects", oid[0:2], oid[2:4], oid), nil
+}
+
+// HasLFSObject reports whether the content of pointer is in the local store
+func (g *GitCommand) HasLFSObject(pointer LFSPointer) bool {
+	path, err := g.lfsObjectPath(pointer.OID)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// ResolveLFS returns the content that data points to if it is a pointer
+// file whose object is in the local store, and data itself otherwise
+func (g *GitCommand) ResolveLFS(data []byte) []byte {
+	pointer, ok := ParseLFSPointer(data)
+	if !ok {
+		return data
+	}
+	path, err := g.lfsObjectPath(pointer.OID)
+	if err != nil {
+		return data
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return data
+	}
+	return content
+}
+
+// LFSChange compares the pointers of both versions of an LFS file
+type LFSChange struct {
+	Path string
+	// Old and New are nil for a side that does not exist
+	Old *LFSPointer
+	New *LFSPointer
+	// OldLocal and NewLocal report whether the objects are in the local store
+	OldLocal bool
+	NewLocal bool
+}
+
+// DiffLFS compares the versions of file that DiffFile compares. It reports
+// false unless one of them is a pointer file
+func (g *GitCommand) DiffLFS(file string) (LFSChange, bool, error) {
+	oldData, newData, err := g.FileVersions(file)
+	if err != nil {
+		return LFSChange{}, false, err
+	}
+	change, ok := compareLFS(file, oldData, newData, g.HasLFSObject)
+	return change, ok, nil
+}
+
+// compareLFS compares two versions of file, which are nil for a side that
+// does not exist. hasObject reports whether the object of a pointer is in the
+// local store
+func compareLFS(file string, oldData, newData []byte, hasObject func(LFSPointer) bool) (LFSChange, bool) {
+	_, oldPointer := ParseLFSPointer(oldData)
+	_, newPointer := ParseLFSPointer(newData)
+	if !oldPointer && !newPointer {
+		return LFSChange{}, false
+	}
+
+	change := LFSChange{Path: file}
+	if oldData != nil {
+		pointer := LFSPointerFor(oldData)
+		change.Old, change.OldLocal = &pointer, !oldPointer || hasObject(pointer)
+	}
+	if newData != nil {
+		pointer := LFSPointerFor(newData)
+		change.New, change.NewLocal = &pointer, !newPointer || hasObject(pointer)
+	}
+	return change, true
+}
+
+// LFSPatterns returns the patterns that store files in LFS, from the
+// top-level .gitattributes and those in subdirectories. Patterns of a
+// subdirectory are prefixed with its path
+func (g *GitCommand) LFSPatterns() ([]string, error) {
+	files, err := g.attributesFiles()
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]string, 0)
+	for _, file := range files {
+		lines, err := readAttributes(file.path)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			if pattern, ok := file.lfsPattern(line); ok && !slices.Contains(patterns, pattern) {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, nil
+}
+
+// TrackLFS stores files matching pattern in LFS from now on, writing the
+// same line to the top-level .gitattributes as git lfs track. Files
+// committed before keep their content in git
+func (g *GitCommand) TrackLFS(pattern string) error {
+	patterns, err := g.LFSPatterns()
+	if err != nil || slices.Contains(patterns, pattern) {
+		return err
+	}
+	files, err := g.attributesFiles()
+	if err != nil {
+		return err
+	}
+	lines, err := readAttributes(files[0].path)
+	if err != nil {
+		return err
+	}
+	return writeAttributes(files[0].path, append(lines, pattern+" "+lfsAttributes))
+}
+
+// UntrackLFS removes the LFS lines of pattern, as LFSPatterns returns it,
+// from the .gitattributes file it is in
+func (g *GitCommand) UntrackLFS(pattern string) error {
+	files, err := g.attributesFiles()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		lines, err := readAttributes(file.path)
+		if err != nil {
+			return err
+		}
+		kept := slices.DeleteFunc(slices.Clone(lines), func(line string) bool {
+			tracked, ok := file.lfsPattern(line)
+			return ok && tracked == pattern
+		})
+		if len(kept) == len(lines) {
+			continue
+		}
+		if err := writeAttributes(file.path, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attributesFile is a .gitattributes file of the work tree
+type attributesFile struct {
+	path string
+	// dir is the directory of the file relative to the top level, with a
+	// trailing slash, or empty for the top-level file
+	dir string
+}
+
+// lfsPattern returns the pattern of a line of f that sets the LFS filter,
+// prefixed with the directory of f
+func (f attributesFile) lfsPattern(line string) (string, bool) {
+	pattern, ok := lfsPattern(line)
+	if !ok || f.dir == "" {
+		return pattern, ok
+	}
+	return f.dir + strings.TrimPrefix(pattern, "/"), true
+}
+
+// attributesFiles returns the top-level .gitattributes, which need not
+// exist yet, followed by those of subdirectories that are tracked or not
+// ignored
+func (g *GitCommand) attributesFiles() ([]attributesFile, error) {
+	top, err := g.TopLevel()
+	if err != nil {
+		return nil, err
+	}
+	output, err := g.runCommand("ls-files", "-z", "--full-name", "--cached", "--others", "--exclude-standard", "--", ":(top,glob)**/.gitattributes")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []attributesFile{{path: filepath.Join(top, ".gitattributes")}}
+	names := splitPaths(output)
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if name != ".gitattributes" {
+			files = append(files, attributesFile{
+				path: filepath.Join(top, filepath.FromSlash(name)),
+				dir:  strings.TrimSuffix(name, ".gitattributes"),
+			})
+		}
+	}
+	return files, nil
+}
+
+// lfsPattern returns the pattern of a .gitattributes line that sets the LFS
+// filter
+func lfsPattern(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return "", false
+	}
+	return fields[0], slices.Contains(fields[1:], "filter=lfs")
+}
+
+// readAttributes returns the lines of the .gitattributes file at path, none
+// if it does not exist
+func readAttributes(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return []string{}, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
+
+func writeAttributes(path string, lines []string) error {
+	data := strings.Join(lines, "\n")
+	if data != "" {
+		data += "\n"
+	}
+	return os.WriteFile(path, []byte(data), 0o644)
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newLFSRepository commits files matched by LFS patterns of the top-level
+// .gitattributes and of one in a subdirectory, without running git-lfs
+func newLFSRepository(t *testing.T) string {
+	t.Helper()
+	isolateGit(t)
+	dir := newTestRepository(t)
+	files := diskFixture{t, dir}
+	files.write(".gitattributes", "*.bin "+lfsAttributes+"\n*.txt text\n")
+	files.write("assets/.gitattributes", "*.psd "+lfsAttributes+"\n")
+	files.write("a.bin", "binary\n")
+	files.write("b.txt", "text\n")
+	files.write("assets/c.psd", "image\n")
+	files.write("assets/d.txt", "text\n")
+	files.write("e.psd", "image outside assets\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "Add files")
+	return dir
+}
+
+// withoutGitLFS hides git-lfs from git, which is then run by its full path
+func withoutGitLFS(t *testing.T, command *GitCommand) {
+	t.Helper()
+	binary, err := exec.LookPath("git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.Binary = binary
+	t.Setenv("PATH", t.TempDir())
+}
+
+func TestLFSWithoutGitLFS(t *testing.T) {
+	dir := newLFSRepository(t)
+	command := NewGitCommand(dir)
+	withoutGitLFS(t, command)
+
+	tracked, err := command.LFSTracked([]string{"a.bin", "b.txt", "assets/c.psd", "assets/d.txt", "e.psd"})
+	mustSucceed(t, err)
+	if len(tracked) != 2 || !tracked["a.bin"] || !tracked["assets/c.psd"] {
+		t.Errorf("LFSTracked() = %v, want a.bin and assets/c.psd", tracked)
+	}
+
+	files, err := command.LFSFiles()
+	mustSucceed(t, err)
+	if !slices.Equal(files, []string{"a.bin", "assets/c.psd"}) {
+		t.Errorf("LFSFiles() = %v, want a.bin and assets/c.psd", files)
+	}
+}
+
+func TestLFSPatterns(t *testing.T) {
+	dir := newLFSRepository(t)
+
+	// The patterns are the same seen from a subdirectory
+	for _, workingDir := range []string{dir, filepath.Join(dir, "assets")} {
+		patterns, err := NewGitCommand(workingDir).LFSPatterns()
+		mustSucceed(t, err)
+		if !slices.Equal(patterns, []string{"*.bin", "assets/*.psd"}) {
+			t.Errorf("LFSPatterns() in %s = %v", workingDir, patterns)
+		}
+	}
+
+	command := NewGitCommand(filepath.Join(dir, "assets"))
+	mustSucceed(t, command.TrackLFS("*.iso"))
+	mustSucceed(t, command.TrackLFS("assets/*.psd"))
+	mustSucceed(t, command.UntrackLFS("assets/*.psd"))
+
+	readFile := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		mustSucceed(t, err)
+		return string(data)
+	}
+	if got, want := readFile(".gitattributes"), "*.bin "+lfsAttributes+"\n*.txt text\n*.iso "+lfsAttributes+"\n"; got != want {
+		t.Errorf("top-level .gitattributes is %q, want %q", got, want)
+	}
+	if got := readFile("assets/.gitattributes"); got != "" {
+		t.Errorf("assets/.gitattributes is %q after untracking its pattern", got)
+	}
+}
+
+func TestLFSFilesAreCached(t *testing.T) {
+	dir := newLFSRepository(t)
+	command := NewGitCommand(dir)
+	withoutGitLFS(t, command)
+
+	// A stand-in for git-lfs that counts how often it lists files
+	bin := os.Getenv("PATH")
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\necho >> " + calls + "\necho a.bin\n"
+	mustSucceed(t, os.WriteFile(filepath.Join(bin, "git-lfs"), []byte(script), 0o755))
+	countCalls := func() int {
+		data, _ := os.ReadFile(calls)
+		return strings.Count(string(data), "\n")
+	}
+
+	for range 3 {
+		files, err := command.LFSFiles()
+		mustSucceed(t, err)
+		if !slices.Equal(files, []string{"a.bin"}) {
+			t.Fatalf("LFSFiles() = %v, want what git lfs ls-files listed", files)
+		}
+	}
+	if n := countCalls(); n != 1 {
+		t.Errorf("git lfs ls-files ran %d times for the same HEAD and index", n)
+	}
+
+	diskFixture{t, dir}.write("f.bin", "more binary\n")
+	mustSucceed(t, command.Stage([]string{"f.bin"}))
+	command.LFSFiles()
+	if n := countCalls(); n != 2 {
+		t.Errorf("git lfs ls-files ran %d times after the index changed, want 2", n)
+	}
+
+	// Copies of the command share the cache
+	mustSucceed(t, command.Commit("Add f", CommitOptions{}))
+	for range 2 {
+		command.WithContext(context.Background()).LFSFiles()
+	}
+	if n := countCalls(); n != 3 {
+		t.Errorf("git lfs ls-files ran %d times after a commit, want 3", n)
+	}
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogEntry describes a single commit
+type LogEntry struct {
+	Hash       string
+	Author     string
+	AuthorMail string
+	Date       time.Time
+	Subject    string
+	// Path is the name of the followed file in this commit
+	Path string
+}
+
+const (
+	logRecordSeparator = "\x1e"
+	logFieldSeparator  = "\x1f"
+	logFormat          = "--format=%x1e%H%x1f%an%x1f%ae%x1f%at%x1f%s"
+)
+
+// Log returns the commits reachable from revision, newest first. It returns
+// at most limit entries unless limit is 0, and revision defaults to HEAD
+func (g *GitCommand) Log(revision string, limit int) ([]LogEntry, error) {
+	args := []string{"log", logFormat}
+	if limit > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(limit))
+	}
+	if revision != "" {
+		args = append(args, revision)
+	}
+	output, err := g.runCommand(append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(output), nil
+}
+
+// FileHistory returns every commit that touched file, newest first,
+// following the file across renames
+func (g *GitCommand) FileHistory(file string) ([]LogEntry, error) {
+	output, err := g.runCommand("log", "--follow", "--name-only", logFormat, "--", file)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(output), nil
+}
+
+// FileDiffAtCommit returns the changes a commit made to path
+func (g *GitCommand) FileDiffAtCommit(commit, path string) (string, error) {
+	return g.runCommand("show", "--format=", commit, "--", path)
+}
+
+// CompareFileRevisions returns the diff between fromPath at revision from and
+// toPath at revision to, which may differ when the file was renamed
+func (g *GitCommand) CompareFileRevisions(from, fromPath, to, toPath string) (string, error) {
+	return g.runCommand("diff", from+":"+fromPath, to+":"+toPath)
+}
+
+func parseLog(output string) []LogEntry {
+	entries := make([]LogEntry, 0)
+	for _, record := range strings.Split(output, logRecordSeparator) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		header, files, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, logFieldSeparator)
+		if len(fields) < 5 {
+			continue
+		}
+
+		entry := LogEntry{
+			Hash:       fields[0],
+			Author:     fields[1],
+			AuthorMail: fields[2],
+			Subject:    fields[4],
+		}
+		if seconds, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			entry.Date = time.Unix(seconds, 0)
+		}
+		for _, file := range strings.Split(files, "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				entry.Path = file
+				break
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSummaryLimit is the summary length above which LintMessage warns
+const DefaultSummaryLimit = 72
+
+// MessageIssue is a problem found in a commit message. Errors prevent the
+// commit, warnings are only reported
+type MessageIssue struct {
+	Text  string
+	Error bool
+}
+
+func (i MessageIssue) String() string {
+	if i.Error {
+		return "error: " + i.Text
+	}
+	return "warning: " + i.Text
+}
+
+// LintMessage checks a commit message. The summary is its first line and is
+// required, and it should not be longer than summaryLimit characters. A limit
+// of 0 turns the length check off
+func LintMessage(message string, summaryLimit int) []MessageIssue {
+	issues := make([]MessageIssue, 0)
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+
+	summary := strings.TrimSpace(lines[0])
+	if summary == "" {
+		return append(issues, MessageIssue{Text: "Summary is empty", Error: true})
+	}
+	if length := utf8.RuneCountInString(summary); summaryLimit > 0 && length > summaryLimit {
+		issues = append(issues, MessageIssue{Text: fmt.Sprintf("Summary is %d characters, limit %d", length, summaryLimit)})
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		issues = append(issues, MessageIssue{Text: "Summary is not followed by a blank line"})
+	}
+	return issues
+}
+
+// HasErrors reports whether any of issues prevents a commit
+func HasErrors(issues []MessageIssue) bool {
+	for _, issue := range issues {
+		if issue.Error {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReflogEntry is a commit that HEAD pointed to
+type ReflogEntry struct {
+	Hash string
+	// Selector names the entry, such as HEAD@{2}
+	Selector string
+	// Action describes how HEAD got there, such as "commit: Fix typo"
+	Action string
+	Date   time.Time
+}
+
+// Reflog returns the previous positions of HEAD, newest first. It returns at
+// most limit entries unless limit is 0
+func (g *GitCommand) Reflog(limit int) ([]ReflogEntry, error) {
+	args := []string{"reflog", "show", "--format=%x1e%H%x1f%gd%x1f%gs%x1f%ct"}
+	if limit > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(limit))
+	}
+	output, err := g.runCommand(append(args, "HEAD", "--")...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ReflogEntry, 0)
+	for _, record := range strings.Split(output, logRecordSeparator) {
+		fields := strings.Split(strings.TrimSpace(record), logFieldSeparator)
+		if len(fields) < 4 {
+			continue
+		}
+		entry := ReflogEntry{Hash: fields[0], Selector: fields[1], Action: fields[2]}
+		if seconds, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			entry.Date = time.Unix(seconds, 0)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Checkout switches to a branch, or detaches HEAD at any other revision
+func (g *GitCommand) Checkout(revision string) error {
+	_, err := g.runCommand("checkout", revision, "--")
+	return err
+}
@@ -0,0 +1,232 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoUpstream is returned by Push when the current branch has no upstream
+// branch configured and no explicit remote was given
+var ErrNoUpstream = errors.New("current branch has no upstream branch")
+
+// ErrBranchNeedsRemote is returned by Push when a branch is given without the
+// remote to push it to
+var ErrBranchNeedsRemote = errors.New("a branch can only be pushed to an explicit remote")
+
+// ErrDetachedHead is returned by Push when asked to set the upstream of the
+// current branch while HEAD is not on a branch
+var ErrDetachedHead = errors.New("HEAD is detached, there is no branch to push")
+
+// ErrAuthentication is wrapped in the error of a remote command when the
+// remote rejected the credentials it was given
+var ErrAuthentication = errors.New("authentication failed")
+
+// authFailures are lowercase parts of the messages git and ssh print when a
+// remote rejects credentials
+var authFailures = []string{
+	"authentication failed",
+	"permission denied",
+	"invalid username or password",
+	"could not read username",
+	"could not read password",
+	"access denied",
+}
+
+// runRemoteCommand runs a git command that talks to a remote. If it fails
+// because of the credentials, its error wraps ErrAuthentication
+func (g *GitCommand) runRemoteCommand(args ...string) error {
+	_, stderr, err := g.Run(args...)
+	if err == nil {
+		return nil
+	}
+	lower := strings.ToLower(stderr)
+	for _, failure := range authFailures {
+		if strings.Contains(lower, failure) {
+			return fmt.Errorf("%w: %s", ErrAuthentication, strings.TrimSpace(stderr))
+		}
+	}
+	return err
+}
+
+// Remote represents a configured remote repository
+type Remote struct {
+	Name     string
+	FetchURL string
+	PushURL  string
+}
+
+// PushOptions configures a push to a remote repository
+type PushOptions struct {
+	Remote         string
+	Branch         string
+	SetUpstream    bool
+	ForceWithLease bool
+}
+
+// PullOptions configures a pull from a remote repository
+type PullOptions struct {
+	Remote          string
+	Branch          string
+	Rebase          bool
+	FastForwardOnly bool
+}
+
+// FetchOptions configures a fetch from one or all remote repositories
+type FetchOptions struct {
+	Remote string
+	All    bool
+	Prune  bool
+}
+
+// GetRemotes returns all configured remotes with their fetch and push URLs
+func (g *GitCommand) GetRemotes() ([]Remote, error) {
+	output, err := g.runCommand("remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := make([]Remote, 0)
+	index := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+
+		name, url, kind := fields[0], fields[1], fields[2]
+		i, ok := index[name]
+		if !ok {
+			i = len(remotes)
+			index[name] = i
+			remotes = append(remotes, Remote{Name: name})
+		}
+
+		switch kind {
+		case "(fetch)":
+			remotes[i].FetchURL = url
+		case "(push)":
+			remotes[i].PushURL = url
+		}
+	}
+
+	return remotes, nil
+}
+
+// AddRemote adds a new remote with the given name and URL
+func (g *GitCommand) AddRemote(name, url string) error {
+	_, err := g.runCommand("remote", "add", name, url)
+	return err
+}
+
+// RenameRemote renames an existing remote
+func (g *GitCommand) RenameRemote(oldName, newName string) error {
+	_, err := g.runCommand("remote", "rename", oldName, newName)
+	return err
+}
+
+// RemoveRemote removes a remote and its remote-tracking branches
+func (g *GitCommand) RemoveRemote(name string) error {
+	_, err := g.runCommand("remote", "remove", name)
+	return err
+}
+
+// SetRemoteURL changes the URL of a remote, or only its push URL if push is set
+func (g *GitCommand) SetRemoteURL(name, url string, push bool) error {
+	args := []string{"remote", "set-url"}
+	if push {
+		args = append(args, "--push")
+	}
+	args = append(args, name, url)
+	_, err := g.runCommand(args...)
+	return err
+}
+
+// CurrentBranch returns the name of the checked out branch
+func (g *GitCommand) CurrentBranch() (string, error) {
+	output, err := g.runCommand("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Upstream returns the upstream branch of the current branch, such as origin/main
+func (g *GitCommand) Upstream() (string, error) {
+	output, err := g.runCommand("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// HasUpstream reports whether the current branch tracks a remote branch
+func (g *GitCommand) HasUpstream() bool {
+	upstream, err := g.Upstream()
+	return err == nil && upstream != ""
+}
+
+// DefaultRemote returns the remote new branches should be pushed to,
+// preferring origin when several remotes are configured
+func (g *GitCommand) DefaultRemote() (string, error) {
+	remotes, err := g.GetRemotes()
+	if err != nil {
+		return "", err
+	}
+	if len(remotes) == 0 {
+		return "", errors.New("no remotes configured")
+	}
+	for _, remote := range remotes {
+		if remote.Name == "origin" {
+			return remote.Name, nil
+		}
+	}
+	return remotes[0].Name, nil
+}
+
+func (o PushOptions) args() []string {
+	args := []string{"push"}
+	if o.SetUpstream {
+		args = append(args, "--set-upstream")
+	}
+	if o.ForceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	if o.Remote != "" {
+		args = append(args, o.Remote)
+		if o.Branch != "" {
+			args = append(args, o.Branch)
+		}
+	}
+	return args
+}
+
+func (o PullOptions) args() []string {
+	args := []string{"pull"}
+	if o.Rebase {
+		args = append(args, "--rebase")
+	}
+	if o.FastForwardOnly {
+		args = append(args, "--ff-only")
+	}
+	if o.Remote != "" {
+		args = append(args, o.Remote)
+		if o.Branch != "" {
+			args = append(args, o.Branch)
+		}
+	}
+	return args
+}
+
+func (o FetchOptions) args() []string {
+	args := []string{"fetch"}
+	if o.Prune {
+		args = append(args, "--prune")
+	}
+	if o.All {
+		args = append(args, "--all")
+	} else if o.Remote != "" {
+		args = append(args, o.Remote)
+	}
+	return args
+}
@@ -0,0 +1,48 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeGit makes command run a script that prints stderr and fails
+func fakeGit(t *testing.T, command *GitCommand, stderr string) {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "git")
+	script := "#!/bin/sh\necho '" + stderr + "' >&2\nexit 128\n"
+	mustSucceed(t, os.WriteFile(binary, []byte(script), 0o755))
+	command.Binary = binary
+}
+
+func TestFetchAuthenticationError(t *testing.T) {
+	command := NewGitCommand(t.TempDir())
+
+	fakeGit(t, command, "fatal: Authentication failed for 'https://example.com/repo.git/'")
+	if err := command.Fetch(FetchOptions{}); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("Fetch() with rejected credentials = %v, want ErrAuthentication", err)
+	}
+
+	fakeGit(t, command, "fatal: couldn't find remote ref main")
+	if err := command.Fetch(FetchOptions{}); err == nil || errors.Is(err, ErrAuthentication) {
+		t.Errorf("Fetch() of a missing ref = %v, want another error", err)
+	}
+}
+
+func TestPushRejectsAmbiguousTargets(t *testing.T) {
+	isolateGit(t)
+	dir := newTestRepository(t)
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "first")
+	runGit(t, dir, "remote", "add", "origin", t.TempDir())
+	command := NewGitCommand(dir)
+
+	if err := command.Push(PushOptions{Branch: "main"}); !errors.Is(err, ErrBranchNeedsRemote) {
+		t.Errorf("Push() of a branch without a remote = %v, want ErrBranchNeedsRemote", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "--detach")
+	if err := command.Push(PushOptions{SetUpstream: true}); !errors.Is(err, ErrDetachedHead) {
+		t.Errorf("Push() setting the upstream of a detached HEAD = %v, want ErrDetachedHead", err)
+	}
+}
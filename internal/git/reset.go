@@ -0,0 +1,114 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResetMode is how far ResetTo goes beyond moving the current branch
+type ResetMode string
+
+const (
+	// ResetSoft only moves the branch
+	ResetSoft ResetMode = "soft"
+	// ResetMixed also resets the index
+	ResetMixed ResetMode = "mixed"
+	// ResetHard also resets the working tree, dropping all local changes
+	ResetHard ResetMode = "hard"
+	// ResetKeep resets the working tree but keeps local changes, and fails
+	// if they are in files that differ between HEAD and the revision
+	ResetKeep ResetMode = "keep"
+)
+
+// ResetModes lists the modes in the order they are offered
+var ResetModes = []ResetMode{ResetSoft, ResetMixed, ResetKeep, ResetHard}
+
+// UpdatesWorkTree reports whether resetting with m changes files in the
+// working tree
+func (m ResetMode) UpdatesWorkTree() bool {
+	return m == ResetHard || m == ResetKeep
+}
+
+// ResetTo moves the current branch, or HEAD if it is detached, to revision
+func (g *GitCommand) ResetTo(revision string, mode ResetMode) error {
+	_, err := g.runCommand("reset", "--"+string(mode), revision, "--")
+	return err
+}
+
+// ResetLoss is what resetting the current branch would leave behind
+type ResetLoss struct {
+	// Commits are no longer reachable from the branch after the reset
+	Commits []LogEntry
+	// Files have uncommitted changes that a hard reset throws away
+	Files []FileChange
+}
+
+// PreviewReset reports what resetting to revision loses. Files are only
+// listed for hard resets, since the other modes keep local changes
+func (g *GitCommand) PreviewReset(revision string, mode ResetMode) (ResetLoss, error) {
+	commits, err := g.Log(revision+"..HEAD", 0)
+	if err != nil {
+		return ResetLoss{}, err
+	}
+	loss := ResetLoss{Commits: commits, Files: make([]FileChange, 0)}
+	if mode != ResetHard {
+		return loss, nil
+	}
+
+	loss.Files, err = g.ChangedFiles(Comparison{From: "HEAD"})
+	if err != nil {
+		return ResetLoss{}, err
+	}
+	return loss, nil
+}
+
+// RestoreFile replaces file in the working tree with the content source had
+// at revision. source is file itself unless the file was renamed since. The
+// index is left as it is
+func (g *GitCommand) RestoreFile(revision, source, file string) error {
+	if source == file {
+		_, err := g.runCommand("restore", "--source="+revision, "--", file)
+		return err
+	}
+
+	output, err := g.runCommand("ls-tree", "-z", "--full-tree", revision, "--", source)
+	if err != nil {
+		return err
+	}
+	mode, _, _ := strings.Cut(output, " ")
+	if mode == "" {
+		return fmt.Errorf("%s does not exist in %s", source, revision)
+	}
+	// Converts line endings and runs smudge filters such as LFS as checking
+	// out file would
+	content, err := g.runCommand("cat-file", "--filters", "--path="+file, revision+":"+source)
+	if err != nil {
+		return err
+	}
+
+	return g.writeWorkTreeFile(file, mode, []byte(content))
+}
+
+// writeWorkTreeFile replaces file in the working tree with content, as a
+// symlink, an executable or a regular file depending on its git mode
+func (g *GitCommand) writeWorkTreeFile(file, mode string, content []byte) error {
+	path := filepath.Join(g.WorkingDir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	switch mode {
+	case "120000":
+		return os.Symlink(string(content), path)
+	case "100755":
+		return os.WriteFile(path, content, 0o755)
+	default:
+		return os.WriteFile(path, content, 0o644)
+	}
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreRenamedFile(t *testing.T) {
+	isolateGit(t)
+	dir := newTestRepository(t)
+	files := diskFixture{t, dir}
+	// Enough unchanged lines for git to see the rename
+	common := strings.Repeat("echo unchanged\n", 10)
+	files.write("old.sh", "#!/bin/sh\n"+common+"echo first\n")
+	mustSucceed(t, os.Chmod(filepath.Join(dir, "old.sh"), 0o755))
+	runGit(t, dir, "add", "old.sh")
+	runGit(t, dir, "commit", "-q", "-m", "Add old.sh")
+	runGit(t, dir, "mv", "old.sh", "new.sh")
+	files.write("new.sh", "#!/bin/sh\n"+common+"echo second\n")
+	runGit(t, dir, "commit", "-q", "-am", "Rename to new.sh")
+
+	command := NewGitCommand(dir)
+	history, err := command.FileHistory("new.sh")
+	mustSucceed(t, err)
+	if len(history) != 2 || history[1].Path != "old.sh" {
+		t.Fatalf("history of new.sh is %+v", history)
+	}
+	first := history[1]
+	mustSucceed(t, command.RestoreFile(first.Hash, first.Path, "new.sh"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "new.sh"))
+	mustSucceed(t, err)
+	if string(data) != "#!/bin/sh\n"+common+"echo first\n" {
+		t.Errorf("new.sh is %q after restoring its first version", data)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "new.sh")); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("restored new.sh is not executable: %v", info.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.sh")); err == nil {
+		t.Errorf("restoring wrote old.sh as well")
+	}
+	if status := runGit(t, dir, "status", "--porcelain"); strings.TrimSpace(status) != "M new.sh" {
+		t.Errorf("after restoring the status is %q", status)
+	}
+
+	// A file that kept its name is restored by git itself
+	mustSucceed(t, command.RestoreFile("HEAD", "new.sh", "new.sh"))
+	if status := runGit(t, dir, "status", "--porcelain"); status != "" {
+		t.Errorf("after restoring HEAD the status is %q", status)
+	}
+}
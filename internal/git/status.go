@@ -0,0 +1,223 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileStatus is the state of a file in the index and in the working tree,
+// using the status letters of git status --porcelain
+type FileStatus struct {
+	Path     string
+	OrigPath string
+	Index    byte
+	WorkTree byte
+}
+
+// Untracked reports whether the file is not known to git
+func (s FileStatus) Untracked() bool {
+	return s.Index == '?'
+}
+
+// Staged reports whether the file has changes in the index
+func (s FileStatus) Staged() bool {
+	return s.Index != ' ' && s.Index != '?' && s.Index != 0
+}
+
+// Unstaged reports whether the file has changes in the working tree that are
+// not in the index
+func (s FileStatus) Unstaged() bool {
+	return s.WorkTree != ' ' && s.WorkTree != '?' && s.WorkTree != 0
+}
+
+// FileDiff is the diff of a single file together with what is needed to
+// summarize binary changes
+type FileDiff struct {
+	Path   string
+	Patch  string
+	Binary bool
+	// TooLarge is set when Patch was left out because the diff exceeds the
+	// limits of its DiffOptions
+	TooLarge bool
+	// OldSize and NewSize are the sizes in bytes of both sides of the diff,
+	// or -1 when that side does not exist
+	OldSize int64
+	NewSize int64
+}
+
+// GetStatus returns the status of every changed, staged or untracked file
+func (g *GitCommand) GetStatus() ([]FileStatus, error) {
+	return g.status()
+}
+
+// GetFileStatus returns the status of a single file. Unchanged files are
+// reported with blank status letters
+func (g *GitCommand) GetFileStatus(file string) (FileStatus, error) {
+	statuses, err := g.status("--", file)
+	if err != nil {
+		return FileStatus{}, err
+	}
+	for _, status := range statuses {
+		if status.Path == file {
+			return status, nil
+		}
+	}
+	return FileStatus{Path: file, Index: ' ', WorkTree: ' '}, nil
+}
+
+func (g *GitCommand) status(pathspec ...string) ([]FileStatus, error) {
+	args := append([]string{"status", "--porcelain=v1", "-z", "--untracked-files=all"}, pathspec...)
+	output, err := g.runCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]FileStatus, 0)
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		status := FileStatus{Index: entry[0], WorkTree: entry[1], Path: entry[3:]}
+		if (status.Index == 'R' || status.Index == 'C') && i+1 < len(fields) {
+			status.OrigPath = fields[i+1]
+			i++
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// DiffFile returns the diff of file against the right base for its state:
+// untracked files are compared with an empty file, staged changes with HEAD,
+// and unstaged changes with the index. Partially staged files show both
+func (g *GitCommand) DiffFile(file string, opts DiffOptions) (FileDiff, error) {
+	status, err := g.GetFileStatus(file)
+	if err != nil {
+		return FileDiff{}, err
+	}
+
+	diff := FileDiff{Path: file, OldSize: -1, NewSize: -1}
+	sources := make([][]string, 0, 2)
+	switch {
+	case status.Untracked():
+		sources = append(sources, []string{"--no-index", "--", os.DevNull, file})
+		diff.NewSize = g.workTreeSize(file)
+	case status.Staged() && status.Unstaged():
+		sources = append(sources, []string{"--cached", "--", file}, []string{"--", file})
+		diff.OldSize = g.objectSize("HEAD:" + file)
+		diff.NewSize = g.workTreeSize(file)
+	case status.Staged():
+		sources = append(sources, []string{"--cached", "--", file})
+		diff.OldSize = g.objectSize("HEAD:" + file)
+		diff.NewSize = g.objectSize(":" + file)
+	default:
+		sources = append(sources, []string{"--", file})
+		diff.OldSize = g.objectSize(":" + file)
+		diff.NewSize = g.workTreeSize(file)
+	}
+
+	changed := 0
+	for _, source := range sources {
+		numstat, err := g.runDiffCommand(append([]string{"diff", "--numstat"}, source...)...)
+		if err != nil {
+			return FileDiff{}, err
+		}
+		if strings.HasPrefix(numstat, "-\t-\t") {
+			diff.Binary = true
+			return diff, nil
+		}
+		for _, stat := range parseNumstat(strings.ReplaceAll(numstat, "\n", "\x00")) {
+			changed += stat.Additions + stat.Deletions
+		}
+	}
+	if opts.exceeded(changed, diff.OldSize, diff.NewSize) {
+		diff.TooLarge = true
+		return diff, nil
+	}
+
+	patches := make([]string, 0, len(sources))
+	for _, source := range sources {
+		args := append([]string{"diff"}, opts.Args()...)
+		patch, err := g.runDiffCommand(append(args, source...)...)
+		if err != nil {
+			return FileDiff{}, err
+		}
+		patches = append(patches, patch)
+	}
+
+	diff.Patch = strings.Join(patches, "")
+	return diff, nil
+}
+
+// objectSize returns the size of a blob such as HEAD:path or :path, or -1 if
+// it does not exist
+func (g *GitCommand) objectSize(object string) int64 {
+	output, err := g.runCommand("cat-file", "-s", object)
+	if err != nil {
+		return -1
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+func (g *GitCommand) workTreeSize(file string) int64 {
+	info, err := os.Stat(filepath.Join(g.WorkingDir, file))
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// FileVersions returns the old and new content of file, taken from the same
+// sides that DiffFile compares. A side that does not exist is returned as nil
+func (g *GitCommand) FileVersions(file string) ([]byte, []byte, error) {
+	status, err := g.GetFileStatus(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case status.Untracked():
+		return nil, g.readWorkTreeFile(file), nil
+	case status.Staged() && status.Unstaged():
+		return g.readBlob("HEAD:" + file), g.readWorkTreeFile(file), nil
+	case status.Staged():
+		return g.readBlob("HEAD:" + file), g.readBlob(":" + file), nil
+	default:
+		return g.readBlob(":" + file), g.readWorkTreeFile(file), nil
+	}
+}
+
+// ReadBlob returns the content of path at revision, or in the index if
+// revision is empty
+func (g *GitCommand) ReadBlob(revision, path string) ([]byte, error) {
+	output, err := g.runCommand("cat-file", "blob", revision+":"+path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+func (g *GitCommand) readBlob(object string) []byte {
+	output, err := g.runCommand("cat-file", "blob", object)
+	if err != nil {
+		return nil
+	}
+	return []byte(output)
+}
+
+func (g *GitCommand) readWorkTreeFile(file string) []byte {
+	data, err := os.ReadFile(filepath.Join(g.WorkingDir, file))
+	if err != nil {
+		return nil
+	}
+	return data
+}
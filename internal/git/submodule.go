@@ -0,0 +1,176 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// submoduleMode is the file mode git records for a submodule in a tree
+const submoduleMode = "160000"
+
+// Submodule is a repository embedded in the working tree
+type Submodule struct {
+	Name string
+	Path string
+	URL  string
+	// Recorded is the commit that the index of the superproject records
+	Recorded string
+	// Current is the commit checked out in the submodule, empty if it is
+	// not initialized
+	Current string
+	// Dirty is set when the submodule has uncommitted or untracked changes
+	Dirty bool
+}
+
+// Initialized reports whether the submodule is checked out
+func (s Submodule) Initialized() bool {
+	return s.Current != ""
+}
+
+// Moved reports whether the checked-out commit differs from the recorded one
+func (s Submodule) Moved() bool {
+	return s.Initialized() && s.Current != s.Recorded
+}
+
+// Submodule returns a GitCommand for the submodule at path, sharing the
+// settings of g
+func (g *GitCommand) Submodule(path string) *GitCommand {
+	child := *g
+	child.WorkingDir = filepath.Join(g.WorkingDir, path)
+	return &child
+}
+
+// Submodules lists the submodules of the repository in the order of the
+// index. Repositories without a .gitmodules file are not asked
+func (g *GitCommand) Submodules() ([]Submodule, error) {
+	submodules := make([]Submodule, 0)
+	if _, err := os.Stat(filepath.Join(g.WorkingDir, ".gitmodules")); errors.Is(err, fs.ErrNotExist) {
+		return submodules, nil
+	}
+
+	output, err := g.runCommand("ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, err
+	}
+	names, urls := g.submoduleConfig()
+	for _, entry := range strings.Split(strings.TrimSuffix(output, "\x00"), "\x00") {
+		info, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) < 2 || fields[0] != submoduleMode {
+			continue
+		}
+
+		submodule := Submodule{Name: names[path], Path: path, Recorded: fields[1]}
+		submodule.URL = urls[submodule.Name]
+		if submodule.Name == "" {
+			submodule.Name = path
+		}
+		g.readSubmoduleState(&submodule)
+		submodules = append(submodules, submodule)
+	}
+	return submodules, nil
+}
+
+// submoduleConfig reads .gitmodules into the names of the submodules by path
+// and their URLs by name
+func (g *GitCommand) submoduleConfig() (map[string]string, map[string]string) {
+	names := make(map[string]string)
+	urls := make(map[string]string)
+	output, err := g.runCommand("config", "--file", ".gitmodules", "-z", "--get-regexp", `^submodule\..*\.(path|url)$`)
+	if err != nil {
+		return names, urls
+	}
+	for _, entry := range strings.Split(strings.TrimSuffix(output, "\x00"), "\x00") {
+		key, value, _ := strings.Cut(entry, "\n")
+		name := strings.TrimPrefix(key, "submodule.")
+		switch {
+		case strings.HasSuffix(name, ".path"):
+			names[value] = strings.TrimSuffix(name, ".path")
+		case strings.HasSuffix(name, ".url"):
+			urls[strings.TrimSuffix(name, ".url")] = value
+		}
+	}
+	return names, urls
+}
+
+// readSubmoduleState fills in the checked-out commit and dirty state. An
+// uninitialized submodule is an empty directory, in which git would find
+// the superproject instead
+func (g *GitCommand) readSubmoduleState(submodule *Submodule) {
+	if _, err := os.Stat(filepath.Join(g.WorkingDir, submodule.Path, ".git")); err != nil {
+		return
+	}
+	child := g.Submodule(submodule.Path)
+	head, err := child.runCommand("rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return
+	}
+	submodule.Current = strings.TrimSpace(head)
+	if status, err := child.runCommand("status", "--porcelain"); err == nil {
+		submodule.Dirty = strings.TrimSpace(status) != ""
+	}
+}
+
+// InitSubmodules registers the URLs of the submodules at paths, or of all
+// submodules if paths is empty
+func (g *GitCommand) InitSubmodules(paths []string) error {
+	_, err := g.runCommand(append([]string{"submodule", "init", "--"}, paths...)...)
+	return err
+}
+
+// UpdateSubmodules checks out the recorded commits of the submodules at
+// paths and of their own submodules, cloning them where needed
+func (g *GitCommand) UpdateSubmodules(paths []string) error {
+	return g.runRemoteCommand(append([]string{"submodule", "update", "--init", "--recursive", "--"}, paths...)...)
+}
+
+// SyncSubmodules copies the URLs of .gitmodules into the configuration of
+// the superproject and of the submodules
+func (g *GitCommand) SyncSubmodules(paths []string) error {
+	_, err := g.runCommand(append([]string{"submodule", "sync", "--recursive", "--"}, paths...)...)
+	return err
+}
+
+// SubmoduleChange is how the commit of a submodule differs between HEAD of
+// the superproject and the working tree
+type SubmoduleChange struct {
+	Submodule
+	// Old is the commit recorded in HEAD, empty if the submodule is new
+	Old string
+	// New is the checked-out commit, or the recorded one if the submodule
+	// is not initialized
+	New string
+	// Added are the commits in New but not in Old, Removed those in Old but
+	// not in New. Both are empty if the submodule does not have the commits
+	Added   []LogEntry
+	Removed []LogEntry
+}
+
+// DiffSubmodule describes the change of the submodule s
+func (g *GitCommand) DiffSubmodule(s Submodule) SubmoduleChange {
+	change := SubmoduleChange{Submodule: s, New: s.Recorded}
+	if s.Initialized() {
+		change.New = s.Current
+	}
+	if old, err := g.runCommand("rev-parse", "--verify", "--quiet", "HEAD:"+s.Path); err == nil {
+		change.Old = strings.TrimSpace(old)
+	}
+	if change.Old == "" || change.Old == change.New || !s.Initialized() {
+		return change
+	}
+
+	child := g.Submodule(s.Path)
+	added, err := child.Log(change.Old+".."+change.New, 0)
+	if err != nil {
+		return change
+	}
+	removed, err := child.Log(change.New+".."+change.Old, 0)
+	if err != nil {
+		return change
+	}
+	change.Added, change.Removed = added, removed
+	return change
+}
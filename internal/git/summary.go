@@ -0,0 +1,103 @@
+package git
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Summary is an overview of the state of a repository
+type Summary struct {
+	Branch   string
+	Upstream string
+	// Dirty counts the changed, staged and untracked files
+	Dirty  int
+	Ahead  int
+	Behind int
+	// LastFetch is when the repository was last fetched, or zero if never
+	LastFetch time.Time
+}
+
+// Summary returns the branch, the number of changed files and how far the
+// branch is ahead of and behind its upstream
+func (g *GitCommand) Summary() (Summary, error) {
+	output, err := g.runCommand("status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := parseStatusSummary(output)
+
+	fetchHead, err := g.runCommand("rev-parse", "--git-path", "FETCH_HEAD")
+	if err != nil {
+		return summary, err
+	}
+	path := strings.TrimSpace(fetchHead)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.WorkingDir, path)
+	}
+	if info, err := os.Stat(path); err == nil {
+		summary.LastFetch = info.ModTime()
+	}
+	return summary, nil
+}
+
+func parseStatusSummary(output string) Summary {
+	var summary Summary
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			summary.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			summary.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				summary.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				summary.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			summary.Dirty++
+		}
+	}
+	return summary
+}
+
+// FindRepositories returns the work trees below roots, looking at most
+// maxDepth directories deep. It does not descend into repositories, hidden
+// directories or node_modules. Directories that cannot be read are skipped
+func FindRepositories(roots []string, maxDepth int) []string {
+	repositories := make([]string, 0)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.IsDir() {
+				return nil
+			}
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+				repositories = append(repositories, path)
+				return filepath.SkipDir
+			}
+			if depth(root, path) >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+	return repositories
+}
+
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
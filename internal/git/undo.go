@@ -0,0 +1,246 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshot is the state of a repository before an operation that can lose
+// work, recorded so that the operation can be undone
+type Snapshot struct {
+	// Head is the commit HEAD pointed to, empty on a branch without commits
+	Head string
+	// Branch is the checked-out branch, empty when HEAD was detached
+	Branch string
+	// Refs maps the refs the operation moves to their commits. Operations
+	// only move the checked-out branch, so other branches are left alone
+	// when restoring
+	Refs map[string]string
+	// Index is the tree written from the index, empty if it had conflicts
+	Index string
+	// Files maps the paths of the saved working tree files to their content
+	Files map[string]SavedFile
+	// WorkTree is set when the operation updates the working tree, so that
+	// restoring checks out Head instead of only moving the refs
+	WorkTree bool
+}
+
+// SavedFile is a working tree file saved in a snapshot
+type SavedFile struct {
+	// Blob holds the content of the file or the target of a symlink. It is
+	// empty if the file did not exist
+	Blob string
+	// Mode is the mode git would record for the file: 100644, 100755 or
+	// 120000 for a symlink
+	Mode string
+}
+
+// SnapshotOptions chooses what TakeSnapshot saves besides HEAD, the
+// checked-out branch and the index
+type SnapshotOptions struct {
+	// Files are working tree files whose content is written to the object
+	// database, such as the files about to be discarded
+	Files []string
+	// WorkTree marks operations that update the working tree
+	WorkTree bool
+}
+
+// TakeSnapshot records the current state of the repository. Saved file
+// contents are stored as loose objects, which git keeps for at least two
+// weeks even though nothing refers to them
+func (g *GitCommand) TakeSnapshot(opts SnapshotOptions) (Snapshot, error) {
+	snapshot := Snapshot{
+		Refs:     make(map[string]string),
+		Files:    make(map[string]SavedFile),
+		WorkTree: opts.WorkTree,
+	}
+
+	if head, err := g.runCommand("rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		snapshot.Head = strings.TrimSpace(head)
+	}
+	if branch, err := g.runCommand("symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		snapshot.Branch = strings.TrimSpace(branch)
+	}
+
+	if snapshot.Branch != "" && snapshot.Head != "" {
+		snapshot.Refs["refs/heads/"+snapshot.Branch] = snapshot.Head
+	}
+
+	if tree, err := g.runCommand("write-tree"); err == nil {
+		snapshot.Index = strings.TrimSpace(tree)
+	}
+
+	for _, file := range opts.Files {
+		saved, err := g.saveFile(file)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Files[file] = saved
+	}
+	return snapshot, nil
+}
+
+// saveFile writes the content of a working tree file to the object database
+// as it is on disk, without running filters such as LFS
+func (g *GitCommand) saveFile(file string) (SavedFile, error) {
+	path := filepath.Join(g.WorkingDir, file)
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return SavedFile{}, nil
+	}
+	if err != nil {
+		return SavedFile{}, err
+	}
+
+	saved := SavedFile{Mode: "100644"}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		// hash-object follows symlinks, so their target is hashed from a
+		// temporary file
+		target, err := os.Readlink(path)
+		if err != nil {
+			return SavedFile{}, err
+		}
+		temp, err := os.CreateTemp("", "gleam-symlink-")
+		if err != nil {
+			return SavedFile{}, err
+		}
+		defer os.Remove(temp.Name())
+		_, err = temp.WriteString(target)
+		if closeErr := temp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return SavedFile{}, err
+		}
+		path, saved.Mode = temp.Name(), "120000"
+	case info.Mode().Perm()&0o111 != 0:
+		saved.Mode = "100755"
+	}
+
+	blob, err := g.runCommand("hash-object", "-w", "--no-filters", "--", path)
+	if err != nil {
+		return SavedFile{}, err
+	}
+	saved.Blob = strings.TrimSpace(blob)
+	return saved, nil
+}
+
+// undoReflogMessage is the reflog entry of refs moved by RestoreSnapshot
+const undoReflogMessage = "undo"
+
+// RestoreSnapshot puts the repository back into the state of s. Only the
+// refs recorded in s are moved. When s updated the working tree, local
+// changes are kept as with reset --keep, and restoring stops if they conflict
+func (g *GitCommand) RestoreSnapshot(s Snapshot) error {
+	for name, hash := range s.Refs {
+		if name == "refs/heads/"+s.Branch && s.WorkTree {
+			continue
+		}
+		if current, err := g.runCommand("rev-parse", "--verify", "--quiet", name); err == nil && strings.TrimSpace(current) == hash {
+			continue
+		}
+		if _, err := g.runCommand("update-ref", "-m", undoReflogMessage, name, hash); err != nil {
+			return err
+		}
+	}
+
+	if err := g.restoreHead(s); err != nil {
+		return err
+	}
+
+	if s.Index != "" {
+		if _, err := g.runCommand("read-tree", s.Index); err != nil {
+			return err
+		}
+	}
+
+	for file, saved := range s.Files {
+		if saved.Blob == "" {
+			if err := os.Remove(filepath.Join(g.WorkingDir, file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if err := g.writeWorkTreeFile(file, saved.Mode, g.readBlob(saved.Blob)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreHead points HEAD at the branch or commit of s
+func (g *GitCommand) restoreHead(s Snapshot) error {
+	switch {
+	case s.Branch == "" && s.WorkTree:
+		_, err := g.runCommand("checkout", "--detach", s.Head)
+		return err
+	case s.Branch == "":
+		_, err := g.runCommand("update-ref", "-m", undoReflogMessage, "--no-deref", "HEAD", s.Head)
+		return err
+	}
+
+	if s.WorkTree {
+		if current, err := g.CurrentBranch(); err != nil || current != s.Branch {
+			if _, err := g.runCommand("checkout", s.Branch); err != nil {
+				return err
+			}
+		}
+		_, err := g.runCommand("reset", "--keep", s.Head)
+		return err
+	}
+
+	if current, err := g.CurrentBranch(); err != nil || current != s.Branch {
+		if _, err := g.runCommand("symbolic-ref", "-m", undoReflogMessage, "HEAD", "refs/heads/"+s.Branch); err != nil {
+			return err
+		}
+	}
+	if s.Head == "" {
+		// The branch had no commits yet
+		_, err := g.runCommand("update-ref", "-m", undoReflogMessage, "-d", "refs/heads/"+s.Branch)
+		return err
+	}
+	return nil
+}
+
+// Discard drops the staged and unstaged changes of files, reverting them to
+// their content at HEAD. Files that are not in HEAD are deleted
+func (g *GitCommand) Discard(files []string) error {
+	tracked := make(map[string]bool)
+	if output, err := g.runCommand(append([]string{"ls-tree", "-r", "--name-only", "HEAD", "--"}, files...)...); err == nil {
+		for _, file := range strings.Split(strings.TrimSpace(output), "\n") {
+			tracked[file] = true
+		}
+	}
+
+	var restore, remove []string
+	for _, file := range files {
+		if tracked[file] {
+			restore = append(restore, file)
+		} else {
+			remove = append(remove, file)
+		}
+	}
+
+	if len(restore) > 0 {
+		args := append([]string{"checkout", "HEAD", "--"}, restore...)
+		if _, err := g.runCommand(args...); err != nil {
+			return err
+		}
+	}
+	if len(remove) > 0 {
+		args := append([]string{"rm", "--cached", "--quiet", "--ignore-unmatch", "--"}, remove...)
+		if _, err := g.runCommand(args...); err != nil {
+			return err
+		}
+		for _, file := range remove {
+			if err := os.Remove(filepath.Join(g.WorkingDir, file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreSnapshotMovesOnlyRecordedRefs(t *testing.T) {
+	isolateGit(t)
+	dir := newTestRepository(t)
+	files := diskFixture{t, dir}
+	command := NewGitCommand(dir)
+
+	files.write("a.txt", "1\n")
+	mustSucceed(t, command.Stage([]string{"a.txt"}))
+	mustSucceed(t, command.Commit("First", CommitOptions{}))
+	first := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "branch", "other")
+	snapshot, err := command.TakeSnapshot(SnapshotOptions{})
+	mustSucceed(t, err)
+
+	files.write("a.txt", "2\n")
+	mustSucceed(t, command.Stage([]string{"a.txt"}))
+	mustSucceed(t, command.Commit("Second", CommitOptions{}))
+	// A branch the operation did not move
+	runGit(t, dir, "branch", "--force", "other", "HEAD")
+	second := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	mustSucceed(t, command.RestoreSnapshot(snapshot))
+	if head := strings.TrimSpace(runGit(t, dir, "rev-parse", "main")); head != first {
+		t.Errorf("main is at %s after restoring, want %s", head, first)
+	}
+	if other := strings.TrimSpace(runGit(t, dir, "rev-parse", "other")); other != second {
+		t.Errorf("restoring moved other to %s, want it left at %s", other, second)
+	}
+}
+
+func TestRestoreSnapshotKeepsFileModes(t *testing.T) {
+	isolateGit(t)
+	dir := newTestRepository(t)
+	files := diskFixture{t, dir}
+	files.write("run.sh", "#!/bin/sh\necho saved\n")
+	mustSucceed(t, os.Chmod(filepath.Join(dir, "run.sh"), 0o755))
+	mustSucceed(t, os.Symlink("run.sh", filepath.Join(dir, "link")))
+
+	command := NewGitCommand(dir)
+	snapshot, err := command.TakeSnapshot(SnapshotOptions{Files: []string{"run.sh", "link"}})
+	mustSucceed(t, err)
+
+	files.write("run.sh", "#!/bin/sh\necho changed\n")
+	mustSucceed(t, os.Chmod(filepath.Join(dir, "run.sh"), 0o644))
+	mustSucceed(t, os.Remove(filepath.Join(dir, "link")))
+	files.write("link", "not a link\n")
+	mustSucceed(t, command.RestoreSnapshot(snapshot))
+
+	info, err := os.Lstat(filepath.Join(dir, "run.sh"))
+	mustSucceed(t, err)
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("restored run.sh has mode %v, want it executable", info.Mode())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "run.sh")); string(data) != "#!/bin/sh\necho saved\n" {
+		t.Errorf("restored run.sh is %q", data)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "link")); err != nil || target != "run.sh" {
+		t.Errorf("restored link points to %q, %v; want run.sh", target, err)
+	}
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"strings"
+)
+
+// Worktree is a working tree attached to the repository. The first one
+// listed is the main working tree
+type Worktree struct {
+	Path string
+	Head string
+	// Branch is the checked-out branch, empty when HEAD is detached
+	Branch string
+	Bare   bool
+	Locked bool
+	// LockReason is the reason given when the worktree was locked
+	LockReason string
+	// Prunable is set when the directory of the worktree no longer exists,
+	// so that PruneWorktrees would remove it
+	Prunable       bool
+	PrunableReason string
+}
+
+// Worktrees lists the working trees of the repository, the main one first
+func (g *GitCommand) Worktrees() ([]Worktree, error) {
+	output, err := g.runCommand("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktrees(output), nil
+}
+
+// parseWorktrees parses git worktree list --porcelain, which describes each
+// worktree with one attribute per line and ends it with an empty line
+func parseWorktrees(output string) []Worktree {
+	worktrees := make([]Worktree, 0)
+	for _, record := range strings.Split(strings.TrimSpace(output), "\n\n") {
+		var worktree Worktree
+		for _, line := range strings.Split(record, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				worktree.Path = value
+			case "HEAD":
+				worktree.Head = value
+			case "branch":
+				worktree.Branch = strings.TrimPrefix(value, "refs/heads/")
+			case "bare":
+				worktree.Bare = true
+			case "locked":
+				worktree.Locked, worktree.LockReason = true, value
+			case "prunable":
+				worktree.Prunable, worktree.PrunableReason = true, value
+			}
+		}
+		if worktree.Path != "" {
+			worktrees = append(worktrees, worktree)
+		}
+	}
+	return worktrees
+}
+
+// AddWorktreeOptions describes a new worktree
+type AddWorktreeOptions struct {
+	Path string
+	// Branch is an existing branch to check out
+	Branch string
+	// NewBranch creates a branch at StartPoint, or at HEAD if it is empty,
+	// and checks it out. It takes precedence over Branch
+	NewBranch  string
+	StartPoint string
+}
+
+// AddWorktree creates a worktree at opts.Path
+func (g *GitCommand) AddWorktree(opts AddWorktreeOptions) error {
+	args := []string{"worktree", "add"}
+	switch {
+	case opts.NewBranch != "":
+		args = append(args, "-b", opts.NewBranch, opts.Path)
+		if opts.StartPoint != "" {
+			args = append(args, opts.StartPoint)
+		}
+	case opts.Branch != "":
+		args = append(args, opts.Path, opts.Branch)
+	default:
+		args = append(args, opts.Path)
+	}
+	_, err := g.runCommand(args...)
+	return err
+}
+
+// RemoveWorktree deletes the worktree at path. Without force, git refuses
+// to remove a worktree that has local changes or is locked
+func (g *GitCommand) RemoveWorktree(path string, force bool) error {
+	args := []string{"worktree", "remove"}
+	if force {
+		args = append(args, "--force")
+	}
+	_, err := g.runCommand(append(args, path)...)
+	return err
+}
+
+// PruneWorktrees removes the administrative files of worktrees whose
+// directories were deleted
+func (g *GitCommand) PruneWorktrees() error {
+	_, err := g.runCommand("worktree", "prune")
+	return err
+}
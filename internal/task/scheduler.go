@@ -0,0 +1,186 @@
+// Package task schedules git operations in the background and hands their
+// results back to the UI
+package task
+
+import (
+	"context"
+	"sync"
+)
+
+// Scheduler runs background work. Operations that modify the index run one
+// at a time in submission order, while keyed loads are canceled when a newer
+// load with the same key is started. Results are passed to Deliver so the
+// UI can apply them from a single goroutine. All work is canceled by Close
+type Scheduler struct {
+	deliver func(func())
+	serial  chan func()
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mutex  sync.Mutex
+	latest map[string]*keyedJob
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type keyedJob struct {
+	cancel     context.CancelFunc
+	generation uint64
+}
+
+// NewScheduler creates a scheduler that hands results to deliver
+func NewScheduler(deliver func(func())) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		deliver: deliver,
+		serial:  make(chan func(), 64),
+		ctx:     ctx,
+		cancel:  cancel,
+		latest:  make(map[string]*keyedJob),
+	}
+	go s.runSerial()
+	return s
+}
+
+func (s *Scheduler) runSerial() {
+	for job := range s.serial {
+		job()
+		s.wg.Done()
+	}
+}
+
+// Serial queues work that must not run concurrently with other index
+// modifications, such as staging, committing or pulling. done receives the
+// result on the delivery goroutine and may be nil. Work that has not started
+// when the scheduler is closed is dropped
+func (s *Scheduler) Serial(work func(ctx context.Context) error, done func(error)) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.wg.Add(1)
+	s.mutex.Unlock()
+
+	s.serial <- func() {
+		if s.ctx.Err() != nil {
+			return
+		}
+		err := work(s.ctx)
+		if done != nil {
+			s.deliver(func() { done(err) })
+		}
+	}
+}
+
+// Go runs work concurrently with everything else. done receives the result
+// on the delivery goroutine and may be nil
+func (s *Scheduler) Go(work func(ctx context.Context) error, done func(error)) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.wg.Add(1)
+	s.mutex.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		err := work(s.ctx)
+		if done != nil {
+			s.deliver(func() { done(err) })
+		}
+	}()
+}
+
+// Latest runs work in the background and cancels any earlier work started
+// with the same key. done is only called for the most recent work, so a
+// slow, stale load can never overwrite a newer result
+func Latest[T any](s *Scheduler, key string, work func(ctx context.Context) (T, error), done func(T, error)) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	job, ok := s.latest[key]
+	if !ok {
+		job = &keyedJob{}
+		s.latest[key] = job
+	}
+	if job.cancel != nil {
+		job.cancel()
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	job.cancel = cancel
+	job.generation++
+	generation := job.generation
+	s.wg.Add(1)
+	s.mutex.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+
+		result, err := work(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(func() {
+			if s.isCurrent(key, generation) {
+				done(result, err)
+			}
+		})
+	}()
+}
+
+func (s *Scheduler) isCurrent(key string, generation uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	job, ok := s.latest[key]
+	return ok && job.generation == generation
+}
+
+// Close cancels the context of all work and waits for running work to
+// return. It blocks for as long as work that ignores its context runs
+func (s *Scheduler) Close() {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.closed = true
+	s.cancel()
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	close(s.serial)
+}
+
+// ForEach calls work for every item on at most workers goroutines and
+// returns once all calls finished. Items that have not started when ctx is
+// canceled are skipped
+func ForEach[T any](ctx context.Context, items []T, workers int, work func(ctx context.Context, item T)) {
+	queue := make(chan T)
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				work(ctx, item)
+			}
+		}()
+	}
+
+	for _, item := range items {
+		select {
+		case queue <- item:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,269 @@
+package task
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestScheduler returns a scheduler that delivers results on a single
+// goroutine, as the UI does, and a function that closes it and waits for
+// the delivered results to be applied
+func newTestScheduler() (*Scheduler, func()) {
+	updates := make(chan func(), 64)
+	applied := make(chan struct{})
+	go func() {
+		defer close(applied)
+		for update := range updates {
+			update()
+		}
+	}()
+	s := NewScheduler(func(fn func()) { updates <- fn })
+	return s, func() {
+		s.Close()
+		close(updates)
+		<-applied
+	}
+}
+
+func TestSerialRunsInOrder(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+
+	// Neither slice is locked: serial work never overlaps and results are
+	// delivered on one goroutine
+	var ran, delivered []int
+	finished := make(chan struct{})
+	for i := range 100 {
+		s.Serial(func(context.Context) error {
+			ran = append(ran, i)
+			return nil
+		}, func(error) {
+			delivered = append(delivered, i)
+			if i == 99 {
+				close(finished)
+			}
+		})
+	}
+	<-finished
+	closeScheduler()
+
+	want := make([]int, 100)
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(ran, want) {
+		t.Errorf("serial work ran in order %v", ran)
+	}
+	if !slices.Equal(delivered, want) {
+		t.Errorf("serial results were delivered in order %v", delivered)
+	}
+}
+
+func TestSerialAfterClose(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+	closeScheduler()
+
+	s.Serial(func(context.Context) error {
+		t.Error("work queued after Close ran")
+		return nil
+	}, nil)
+}
+
+func TestGoRunsConcurrently(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+
+	var running sync.WaitGroup
+	running.Add(2)
+	results := 0
+	for range 2 {
+		s.Go(func(context.Context) error {
+			running.Done()
+			// Returns only once both calls are running
+			running.Wait()
+			return nil
+		}, func(error) { results++ })
+	}
+	closeScheduler()
+
+	if results != 2 {
+		t.Errorf("got %d results, want 2", results)
+	}
+}
+
+func TestLatestDeliversOnlyNewest(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+
+	canceled := make(chan struct{})
+	results := make(chan string, 2)
+	done := func(result string, err error) { results <- result }
+	Latest(s, "diff", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(canceled)
+		return "stale", nil
+	}, done)
+	Latest(s, "diff", func(ctx context.Context) (string, error) {
+		<-canceled
+		return "newest", nil
+	}, done)
+
+	if result := <-results; result != "newest" {
+		t.Errorf("delivered %q, want the newest result", result)
+	}
+	closeScheduler()
+	if len(results) > 0 {
+		t.Errorf("delivered %q as well", <-results)
+	}
+}
+
+func TestLatestIgnoresSupersededResults(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+
+	// The loads do not look at their context and all finish, but only the
+	// last one started is delivered
+	release := make(chan struct{})
+	results := make(chan int, 10)
+	for i := range 10 {
+		Latest(s, "history", func(ctx context.Context) (int, error) {
+			<-release
+			return i, nil
+		}, func(result int, err error) { results <- result })
+	}
+	close(release)
+
+	if result := <-results; result != 9 {
+		t.Errorf("delivered load %d, want the last one", result)
+	}
+	closeScheduler()
+	if len(results) > 0 {
+		t.Errorf("delivered load %d as well", <-results)
+	}
+}
+
+func TestLatestKeysAreIndependent(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+	defer closeScheduler()
+
+	results := make(chan string, 2)
+	for _, key := range []string{"diff", "history"} {
+		Latest(s, key, func(ctx context.Context) (string, error) {
+			return key, nil
+		}, func(result string, err error) { results <- result })
+	}
+
+	got := []string{<-results, <-results}
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"diff", "history"}) {
+		t.Errorf("delivered %v, want one result per key", got)
+	}
+}
+
+func TestCloseCancelsLatest(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+
+	started := make(chan struct{})
+	delivered := false
+	Latest(s, "diff", func(ctx context.Context) (bool, error) {
+		close(started)
+		<-ctx.Done()
+		return true, ctx.Err()
+	}, func(bool, error) { delivered = true })
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		closeScheduler()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not cancel the running load")
+	}
+	if delivered {
+		t.Error("canceled load was delivered")
+	}
+}
+
+func TestCloseCancelsRunningWork(t *testing.T) {
+	s, closeScheduler := newTestScheduler()
+
+	started := make(chan struct{}, 2)
+	wait := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.Go(wait, nil)
+	s.Serial(wait, nil)
+	ran := false
+	s.Serial(func(context.Context) error {
+		ran = true
+		return nil
+	}, nil)
+	<-started
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		closeScheduler()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not cancel the running work")
+	}
+	if ran {
+		t.Error("serial work queued before Close ran after it")
+	}
+}
+
+func TestForEach(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+
+	var running, peak atomic.Int32
+	var mutex sync.Mutex
+	seen := make([]int, 0, len(items))
+	ForEach(context.Background(), items, 4, func(ctx context.Context, item int) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mutex.Lock()
+		seen = append(seen, item)
+		mutex.Unlock()
+	})
+
+	slices.Sort(seen)
+	if !slices.Equal(seen, items) {
+		t.Errorf("ForEach visited %d items, want %d", len(seen), len(items))
+	}
+	if peak.Load() > 4 {
+		t.Errorf("ForEach ran %d calls at once, want at most 4", peak.Load())
+	}
+}
+
+func TestForEachStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	ForEach(ctx, make([]int, 1000), 2, func(ctx context.Context, item int) {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+	})
+
+	// Each worker may have taken one more item before seeing the cancel
+	if n := calls.Load(); n > 12 {
+		t.Errorf("ForEach made %d calls after being canceled at 10", n)
+	}
+}
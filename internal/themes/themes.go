@@ -0,0 +1,221 @@
+// Package themes defines the colors used to draw diffs and blame annotations
+// and loads additional themes from the user's config directory
+package themes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the theme used when none was chosen
+const DefaultName = "Default"
+
+// Color is an NRGBA color written as "#rrggbb" or "#rrggbbaa" in theme files
+type Color color.NRGBA
+
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA(c).RGBA()
+}
+
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A))
+}
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseColor(value)
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// ParseColor parses "#rgb", "#rrggbb" or "#rrggbbaa"
+func ParseColor(value string) (Color, error) {
+	hex, ok := strings.CutPrefix(value, "#")
+	if !ok {
+		return Color{}, fmt.Errorf("invalid color %q", value)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	var c Color
+	if len(hex) != 8 {
+		return c, fmt.Errorf("invalid color %q", value)
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return c, fmt.Errorf("invalid color %q", value)
+	}
+	return c, nil
+}
+
+// Palette holds the colors of one light or dark variant. Syntax names the
+// chroma style used to highlight code
+type Palette struct {
+	Syntax       string `json:"syntax"`
+	Added        Color  `json:"added"`
+	Removed      Color  `json:"removed"`
+	Hunk         Color  `json:"hunk"`
+	Header       Color  `json:"header"`
+	HeaderText   Color  `json:"headerText"`
+	Marker       Color  `json:"marker"`
+	Collapsed    Color  `json:"collapsed"`
+	SearchMatch  Color  `json:"searchMatch"`
+	CurrentMatch Color  `json:"currentMatch"`
+	SearchText   Color  `json:"searchText"`
+	BlameOld     Color  `json:"blameOld"`
+	BlameNew     Color  `json:"blameNew"`
+}
+
+// Theme is a named pair of palettes for light and dark appearance
+type Theme struct {
+	Name  string  `json:"name"`
+	Light Palette `json:"light"`
+	Dark  Palette `json:"dark"`
+}
+
+// Palette returns the palette for the light or dark variant
+func (t Theme) Palette(dark bool) Palette {
+	if dark {
+		return t.Dark
+	}
+	return t.Light
+}
+
+var defaultTheme = Theme{
+	Name: DefaultName,
+	Dark: Palette{
+		Syntax:       "monokai",
+		Added:        Color{R: 46, G: 160, B: 46, A: 160},
+		Removed:      Color{R: 203, G: 54, B: 53, A: 160},
+		Hunk:         Color{R: 66, G: 133, B: 244, A: 120},
+		Header:       Color{R: 66, G: 133, B: 244, A: 160},
+		HeaderText:   Color{R: 255, G: 255, B: 255, A: 255},
+		Marker:       Color{R: 255, G: 255, B: 255, A: 200},
+		Collapsed:    Color{R: 128, G: 128, B: 128, A: 60},
+		SearchMatch:  Color{R: 255, G: 200, B: 0, A: 120},
+		CurrentMatch: Color{R: 255, G: 140, B: 0, A: 220},
+		SearchText:   Color{A: 255},
+		BlameOld:     Color{R: 66, G: 90, B: 160, A: 110},
+		BlameNew:     Color{R: 235, G: 150, B: 40, A: 110},
+	},
+	Light: Palette{
+		Syntax:       "github",
+		Added:        Color{R: 172, G: 242, B: 189, A: 200},
+		Removed:      Color{R: 255, G: 184, B: 184, A: 200},
+		Hunk:         Color{R: 221, G: 244, B: 255, A: 255},
+		Header:       Color{R: 200, G: 225, B: 255, A: 255},
+		HeaderText:   Color{R: 24, G: 40, B: 72, A: 255},
+		Marker:       Color{R: 60, G: 60, B: 60, A: 220},
+		Collapsed:    Color{R: 128, G: 128, B: 128, A: 40},
+		SearchMatch:  Color{R: 255, G: 223, B: 93, A: 180},
+		CurrentMatch: Color{R: 255, G: 150, B: 50, A: 230},
+		SearchText:   Color{A: 255},
+		BlameOld:     Color{R: 120, G: 150, B: 220, A: 90},
+		BlameNew:     Color{R: 255, G: 180, B: 80, A: 110},
+	},
+}
+
+// Default returns the built-in theme with the classic green and red colors
+func Default() Theme {
+	return defaultTheme
+}
+
+// Builtin returns the themes that ship with Gleam. Besides the default there
+// are palettes that stay distinguishable with the common forms of color
+// blindness
+func Builtin() []Theme {
+	blueOrange := withDiffColors(defaultTheme, "Color-blind (blue/orange)",
+		Color{R: 0, G: 114, B: 178, A: 170}, Color{R: 230, G: 159, B: 0, A: 170},
+		Color{R: 86, G: 180, B: 233, A: 160}, Color{R: 240, G: 190, B: 90, A: 170})
+
+	tritan := withDiffColors(defaultTheme, "Color-blind (red/teal)",
+		Color{R: 0, G: 150, B: 136, A: 170}, Color{R: 213, G: 94, B: 0, A: 170},
+		Color{R: 128, G: 205, B: 193, A: 180}, Color{R: 244, G: 165, B: 130, A: 180})
+
+	return []Theme{defaultTheme, blueOrange, tritan}
+}
+
+func withDiffColors(base Theme, name string, darkAdded, darkRemoved, lightAdded, lightRemoved Color) Theme {
+	base.Name = name
+	base.Dark.Added, base.Dark.Removed = darkAdded, darkRemoved
+	base.Light.Added, base.Light.Removed = lightAdded, lightRemoved
+	return base
+}
+
+// UserDir is the directory user themes are loaded from
+func UserDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gleam", "themes"), nil
+}
+
+// Load reads every *.json file in dir as a theme. Colors a file leaves out
+// are taken from the default theme. A missing directory is not an error,
+// and files that fail to parse are reported together while the valid themes
+// are still returned
+func Load(dir string) ([]Theme, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	loaded := make([]Theme, 0, len(paths))
+	var errs []error
+	for _, path := range paths {
+		theme, err := loadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		loaded = append(loaded, theme)
+	}
+	return loaded, errors.Join(errs...)
+}
+
+func loadFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	theme := defaultTheme
+	theme.Name = ""
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return Theme{}, err
+	}
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return theme, nil
+}
+
+// Find returns the theme called name, or the first theme if there is none
+func Find(all []Theme, name string) Theme {
+	for _, theme := range all {
+		if theme.Name == name {
+			return theme
+		}
+	}
+	if len(all) == 0 {
+		return defaultTheme
+	}
+	return all[0]
+}
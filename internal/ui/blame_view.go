@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+
+	"gleam/internal/git"
+	"gleam/internal/task"
+	"gleam/internal/themes"
+)
+
+const (
+	viewModeDiff  = "Diff"
+	viewModeBlame = "Blame"
+)
+
+func (app *GleamApp) setViewMode(mode string) {
+	app.mutex.Lock()
+	app.state.viewMode = mode
+	app.state.blame.path = app.state.activeFileDiff
+	app.state.blame.revision = ""
+	app.mutex.Unlock()
+	app.refreshDiffView()
+}
+
+// toggleBlame switches the diff pane between the diff and the blame view
+func (app *GleamApp) toggleBlame() {
+	if app.ui.viewMode.Selected == viewModeBlame {
+		app.ui.viewMode.SetSelected(viewModeDiff)
+	} else {
+		app.ui.viewMode.SetSelected(viewModeBlame)
+	}
+}
+
+// blameRevision switches the blame view to path at revision, or back to the
+// working tree when revision is empty
+func (app *GleamApp) blameRevision(path, revision string) {
+	app.mutex.Lock()
+	app.state.blame.path = path
+	app.state.blame.revision = revision
+	app.mutex.Unlock()
+	app.refreshDiffView()
+}
+
+// refreshBlameView loads the blame of path at revision into the diff pane,
+// falling back to the active file when no path was chosen yet
+func (app *GleamApp) refreshBlameView(activeFile, path, revision string) {
+	if path == "" {
+		path = activeFile
+	}
+	if path == "" {
+		return
+	}
+
+	task.Latest(app.tasks, diffPaneTask, func(ctx context.Context) (func() fyne.CanvasObject, error) {
+		defer app.logTiming("Blame refresh")()
+
+		lines, err := app.git.WithContext(ctx).Blame(path, revision)
+		if err != nil {
+			message := fmt.Sprintf("Cannot blame %s: %v", path, err)
+			return func() fyne.CanvasObject { return widget.NewLabel(message) }, nil
+		}
+		return func() fyne.CanvasObject {
+			return app.createBlameView(path, revision, lines)
+		}, nil
+	}, app.showDiffPaneContent)
+}
+
+func (app *GleamApp) createBlameView(path, revision string, lines []git.BlameLine) fyne.CanvasObject {
+	gutter := NewTappableTextGrid()
+	content := widget.NewTextGrid()
+	content.ShowLineNumbers = true
+	content.TabWidth = app.tabWidth()
+
+	annotations := make([]string, len(lines))
+	contents := make([]string, len(lines))
+	oldest, newest := blameTimeRange(lines)
+	for i, line := range lines {
+		contents[i] = line.Content
+		if i == 0 || lines[i-1].Commit != line.Commit {
+			annotations[i] = blameAnnotation(line)
+		}
+	}
+	gutter.SetText(strings.Join(annotations, "\n"))
+	content.SetText(strings.Join(contents, "\n"))
+
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+	theme := currentDiffTheme()
+	for i, line := range lines {
+		gutter.SetRowStyle(i, &widget.CustomTextGridStyle{
+			BGColor: blameAgeColor(line, oldest, newest, theme.palette),
+		})
+		handleRegularLine(content, i, line.Content, lexer, theme.style, content.TabWidth)
+	}
+
+	gutter.OnTappedRow = func(row int, event *fyne.PointEvent) {
+		if row < len(lines) {
+			app.showBlameMenu(lines[row], event.AbsolutePosition)
+		}
+	}
+	gutter.OnTappedSecondaryRow = gutter.OnTappedRow
+
+	header := container.NewHBox(widget.NewLabel("Blame of " + path))
+	if revision != "" {
+		header.Add(widget.NewLabel("at " + shortHash(revision)))
+		header.Add(widget.NewButton("Back to working tree", func() {
+			app.mutex.RLock()
+			activeFile := app.state.activeFileDiff
+			app.mutex.RUnlock()
+			app.blameRevision(activeFile, "")
+		}))
+	}
+
+	body := container.NewBorder(nil, nil, gutter, nil, content)
+	return container.NewBorder(header, nil, nil, nil, container.NewScroll(body))
+}
+
+func (app *GleamApp) showBlameMenu(line git.BlameLine, pos fyne.Position) {
+	if line.Uncommitted() {
+		return
+	}
+
+	showCommit := fyne.NewMenuItem("Show commit "+shortHash(line.Commit), func() {
+		app.showCommit(line.Commit)
+	})
+	blameParent := fyne.NewMenuItem("Blame parent revision", func() {
+		app.blameRevision(line.PreviousPath, line.PreviousCommit)
+	})
+	blameParent.Disabled = line.PreviousCommit == ""
+
+	menu := fyne.NewMenu("Blame", showCommit, blameParent)
+	widget.ShowPopUpMenuAtPosition(menu, app.ui.window.Canvas(), pos)
+}
+
+func blameAnnotation(line git.BlameLine) string {
+	if line.Uncommitted() {
+		return "Not committed yet"
+	}
+	return fmt.Sprintf("%-8.8s %-16.16s %s", line.Commit, line.Author, line.AuthorTime.Format("2006-01-02"))
+}
+
+func blameTimeRange(lines []git.BlameLine) (time.Time, time.Time) {
+	var oldest, newest time.Time
+	for _, line := range lines {
+		if line.Uncommitted() {
+			continue
+		}
+		if oldest.IsZero() || line.AuthorTime.Before(oldest) {
+			oldest = line.AuthorTime
+		}
+		if line.AuthorTime.After(newest) {
+			newest = line.AuthorTime
+		}
+	}
+	return oldest, newest
+}
+
+// blameAgeColor shades a line between the old and new blame colors of
+// palette by the age of its commit relative to the rest of the file
+func blameAgeColor(line git.BlameLine, oldest, newest time.Time, palette themes.Palette) color.Color {
+	blameOldColor, blameNewColor := palette.BlameOld, palette.BlameNew
+	if line.Uncommitted() {
+		return blameNewColor
+	}
+
+	span := newest.Sub(oldest)
+	if span <= 0 {
+		return blameNewColor
+	}
+
+	t := float64(line.AuthorTime.Sub(oldest)) / float64(span)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.NRGBA{
+		R: mix(blameOldColor.R, blameNewColor.R),
+		G: mix(blameOldColor.G, blameNewColor.G),
+		B: mix(blameOldColor.B, blameNewColor.B),
+		A: mix(blameOldColor.A, blameNewColor.A),
+	}
+}
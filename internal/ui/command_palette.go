@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// paletteEntry is the search field of the command palette. The arrow keys
+// move through the results and Escape closes the palette
+type paletteEntry struct {
+	widget.Entry
+	onMove   func(delta int)
+	onEscape func()
+}
+
+func newPaletteEntry() *paletteEntry {
+	entry := &paletteEntry{}
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+func (e *paletteEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyUp:
+		e.onMove(-1)
+	case fyne.KeyDown:
+		e.onMove(1)
+	case fyne.KeyEscape:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// showCommandPalette lists the available commands, filtered by fuzzy search
+func (w *Workspace) showCommandPalette() {
+	available := w.keymap.available()
+	matches := available
+	selected := 0
+
+	entry := newPaletteEntry()
+	entry.SetPlaceHolder("Type a command")
+
+	list := widget.NewList(
+		func() int { return len(matches) },
+		func() fyne.CanvasObject {
+			key := widget.NewLabel("")
+			key.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, key, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*fyne.Container)
+			cmd := matches[id]
+			row.Objects[0].(*widget.Label).SetText(cmd.title)
+			row.Objects[1].(*widget.Label).SetText(w.keymap.binding(cmd))
+		},
+	)
+
+	var popup *widget.PopUp
+	run := func(id int) {
+		if id < 0 || id >= len(matches) {
+			return
+		}
+		popup.Hide()
+		w.runCommand(matches[id])
+	}
+
+	// Selections made with the keyboard only highlight a command, tapping
+	// one runs it
+	moving := false
+	selectRow := func(id int) {
+		selected = id
+		moving = true
+		list.Select(id)
+		list.ScrollTo(id)
+		moving = false
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		if !moving {
+			run(id)
+		}
+	}
+
+	entry.OnChanged = func(query string) {
+		matches = fuzzyFilter(available, query)
+		list.Refresh()
+		if len(matches) > 0 {
+			selectRow(0)
+		} else {
+			list.UnselectAll()
+		}
+	}
+	entry.OnSubmitted = func(string) { run(selected) }
+	entry.onMove = func(delta int) {
+		if len(matches) > 0 {
+			selectRow(min(max(selected+delta, 0), len(matches)-1))
+		}
+	}
+	entry.onEscape = func() { popup.Hide() }
+
+	canvas := w.window.Canvas()
+	popup = widget.NewModalPopUp(container.NewBorder(entry, nil, nil, nil, list), canvas)
+	popup.Resize(fyne.NewSize(560, 380))
+	popup.Show()
+	canvas.Focus(entry)
+	if len(matches) > 0 {
+		selectRow(0)
+	}
+}
+
+// fuzzyFilter returns the commands whose title matches query, best first
+func fuzzyFilter(all []*command, query string) []*command {
+	type scored struct {
+		cmd   *command
+		score int
+	}
+	results := make([]scored, 0, len(all))
+	for _, cmd := range all {
+		if score := fuzzyScore(query, cmd.title); score >= 0 {
+			results = append(results, scored{cmd, score})
+		}
+	}
+	slices.SortStableFunc(results, func(a, b scored) int { return b.score - a.score })
+
+	matches := make([]*command, len(results))
+	for i, result := range results {
+		matches[i] = result.cmd
+	}
+	return matches
+}
+
+// fuzzyScore returns how well query matches text, or -1 if the characters of
+// query do not appear in text in order. Runs of consecutive characters and
+// characters at the start of a word score higher
+func fuzzyScore(query, text string) int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0
+	}
+
+	runes := []rune(strings.ToLower(text))
+	score, position, previous := 0, 0, -2
+	for _, q := range query {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		found := -1
+		for i := position; i < len(runes); i++ {
+			if runes[i] == q {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return -1
+		}
+
+		score++
+		if found == previous+1 {
+			score += 5
+		}
+		if found == 0 || !unicode.IsLetter(runes[found-1]) {
+			score += 3
+		}
+		previous, position = found, found+1
+	}
+	return score*100 - len(runes)
+}
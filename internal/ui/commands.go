@@ -0,0 +1,351 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+
+	"gleam/internal/git"
+)
+
+// keyBindingsPreferenceKey stores the bindings the user changed, by command
+// ID. An empty binding removes the default one
+const keyBindingsPreferenceKey = "keyBindings"
+
+// command is an action that can be run from the command palette or a key
+// binding. Commands with a repository run on the repository of the selected
+// tab and are not offered while none is open
+type command struct {
+	id         string
+	title      string
+	defaultKey string
+	workspace  func(w *Workspace)
+	repository func(app *GleamApp)
+}
+
+// commands lists every action of the app. Default keys use "Shortcut" for
+// Ctrl, or Cmd on macOS
+func commands() []*command {
+	repo := func(id, title, key string, run func(app *GleamApp)) *command {
+		return &command{id: id, title: title, defaultKey: key, repository: run}
+	}
+	global := func(id, title, key string, run func(w *Workspace)) *command {
+		return &command{id: id, title: title, defaultKey: key, workspace: run}
+	}
+
+	return []*command{
+		global("palette", "Show command palette", "Shortcut+Shift+P", (*Workspace).showCommandPalette),
+		global("open", "Open repository…", "Shortcut+O", (*Workspace).openWithDialog),
+		global("recent", "Recent repositories…", "Shortcut+Shift+O", (*Workspace).showRecentRepositories),
+		global("dashboard", "Show repository dashboard", "", (*Workspace).showDashboard),
+		global("close-tab", "Close repository tab", "Shortcut+W", (*Workspace).closeSelected),
+		global("next-tab", "Next repository tab", "Shortcut+PageDown", func(w *Workspace) { w.selectTab(1) }),
+		global("previous-tab", "Previous repository tab", "Shortcut+PageUp", func(w *Workspace) { w.selectTab(-1) }),
+
+		repo("commit", "Commit", "Shortcut+Return", (*GleamApp).handleCommit),
+		repo("stage", "Stage selected file", "Shortcut+S", func(app *GleamApp) { app.stageSelected(true) }),
+		repo("unstage", "Unstage selected file", "Shortcut+Shift+S", func(app *GleamApp) { app.stageSelected(false) }),
+		repo("next-file", "Next file", "Shortcut+Down", func(app *GleamApp) { app.moveFileSelection(1) }),
+		repo("previous-file", "Previous file", "Shortcut+Up", func(app *GleamApp) { app.moveFileSelection(-1) }),
+		repo("refresh", "Refresh", "Shortcut+R", func(app *GleamApp) {
+			app.refreshFileList()
+			app.refreshDiffView()
+		}),
+		repo("fetch", "Fetch", "Shortcut+Shift+F", func(app *GleamApp) { app.fetch(git.FetchOptions{}) }),
+		repo("fetch-options", "Fetch with options…", "", (*GleamApp).showFetchOptions),
+		repo("pull", "Pull", "Shortcut+T", (*GleamApp).confirmPull),
+		repo("pull-options", "Pull with options…", "", (*GleamApp).showPullOptions),
+		repo("push", "Push", "Shortcut+Shift+K", (*GleamApp).confirmPush),
+		repo("push-options", "Push with options…", "", (*GleamApp).showPushOptions),
+		repo("remotes", "Manage remotes…", "", (*GleamApp).showRemotesDialog),
+		repo("undo", "Undo last action…", "Shortcut+Alt+Z", (*GleamApp).undoLast),
+		repo("reflog", "Browse reflog…", "", (*GleamApp).showReflog),
+		repo("submodules", "Submodules…", "", (*GleamApp).showSubmodules),
+		repo("worktrees", "Worktrees…", "", (*GleamApp).showWorktrees),
+		repo("discard", "Discard changes to selected file…", "", func(app *GleamApp) { app.discardChanges(app.selectedFile()) }),
+		repo("compare", "Compare branches…", "", (*GleamApp).showCompareWindow),
+		repo("history", "Show history of selected file", "Shortcut+H", func(app *GleamApp) {
+			if file := app.selectedFile(); file != "" {
+				app.showFileHistory(file)
+			}
+		}),
+		repo("toggle-blame", "Toggle blame view", "Shortcut+B", (*GleamApp).toggleBlame),
+		repo("find", "Find in diff", "Shortcut+F", (*GleamApp).showFindBar),
+		repo("next-match", "Next match", "F3", func(app *GleamApp) { app.nextMatch(1) }),
+		repo("previous-match", "Previous match", "Shift+F3", func(app *GleamApp) { app.nextMatch(-1) }),
+		repo("next-hunk", "Next hunk", "Alt+Down", func(app *GleamApp) { app.moveInDiff((*DiffView).NextHunk) }),
+		repo("previous-hunk", "Previous hunk", "Alt+Up", func(app *GleamApp) { app.moveInDiff((*DiffView).PreviousHunk) }),
+		repo("next-change", "Next change", "F7", func(app *GleamApp) { app.moveInDiff((*DiffView).NextChange) }),
+		repo("previous-change", "Previous change", "Shift+F7", func(app *GleamApp) { app.moveInDiff((*DiffView).PreviousChange) }),
+		repo("console", "Toggle git console", "Shortcut+Shift+L", (*GleamApp).toggleConsole),
+		repo("preferences", "Preferences…", "Shortcut+Comma", (*GleamApp).showPreferences),
+	}
+}
+
+// keyCombo is a key together with the modifiers held down
+type keyCombo struct {
+	modifier fyne.KeyModifier
+	key      fyne.KeyName
+}
+
+var modifierNames = []struct {
+	name     string
+	modifier fyne.KeyModifier
+}{
+	{"Ctrl", fyne.KeyModifierControl},
+	{"Alt", fyne.KeyModifierAlt},
+	{"Shift", fyne.KeyModifierShift},
+	{"Super", fyne.KeyModifierSuper},
+}
+
+// keyAliases lets bindings name keys whose Fyne names are symbols
+var keyAliases = map[string]fyne.KeyName{
+	"Enter":  fyne.KeyReturn,
+	"Comma":  fyne.KeyComma,
+	"Period": fyne.KeyPeriod,
+	"Slash":  fyne.KeySlash,
+	"Minus":  fyne.KeyMinus,
+	"Equal":  fyne.KeyEqual,
+	"Esc":    fyne.KeyEscape,
+}
+
+// parseKeyCombo parses bindings such as "Shortcut+Shift+P" or "F3"
+func parseKeyCombo(binding string) (keyCombo, error) {
+	parts := strings.Split(binding, "+")
+	combo := keyCombo{}
+	for _, part := range parts[:len(parts)-1] {
+		switch part {
+		case "Shortcut":
+			combo.modifier |= fyne.KeyModifierShortcutDefault
+		case "Ctrl", "Control":
+			combo.modifier |= fyne.KeyModifierControl
+		case "Alt":
+			combo.modifier |= fyne.KeyModifierAlt
+		case "Shift":
+			combo.modifier |= fyne.KeyModifierShift
+		case "Super", "Cmd":
+			combo.modifier |= fyne.KeyModifierSuper
+		default:
+			return keyCombo{}, fmt.Errorf("unknown modifier %q in %q", part, binding)
+		}
+	}
+
+	key := parts[len(parts)-1]
+	if alias, ok := keyAliases[key]; ok {
+		combo.key = alias
+	} else if len(key) == 1 {
+		combo.key = fyne.KeyName(strings.ToUpper(key))
+	} else {
+		combo.key = fyne.KeyName(key)
+	}
+	if combo.key == "" {
+		return keyCombo{}, fmt.Errorf("missing key in %q", binding)
+	}
+	return combo, nil
+}
+
+func (c keyCombo) String() string {
+	parts := make([]string, 0, 5)
+	for _, m := range modifierNames {
+		if c.modifier&m.modifier != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	return strings.Join(append(parts, string(c.key)), "+")
+}
+
+// usesCanvasShortcut reports whether Fyne delivers the combo as a shortcut.
+// Keys without modifiers or with only Shift arrive as typed keys instead
+func (c keyCombo) usesCanvasShortcut() bool {
+	return c.modifier != 0 && c.modifier != fyne.KeyModifierShift
+}
+
+// keymap binds key combos to commands and dispatches key events to them
+type keymap struct {
+	workspace *Workspace
+	// prefs hold the bindings the user changed
+	prefs    fyne.Preferences
+	commands []*command
+	bindings map[keyCombo]*command
+
+	registered []fyne.Shortcut
+	shift      bool
+}
+
+func newKeymap(w *Workspace) *keymap {
+	k := &keymap{workspace: w, prefs: w.app.Preferences(), commands: commands()}
+	k.load()
+	return k
+}
+
+func (k *keymap) loadOverrides() map[string]string {
+	overrides := make(map[string]string)
+	saved := k.prefs.String(keyBindingsPreferenceKey)
+	if saved == "" {
+		return overrides
+	}
+	if err := json.Unmarshal([]byte(saved), &overrides); err != nil {
+		log.Printf("Error loading key bindings: %v", err)
+	}
+	return overrides
+}
+
+// binding returns the key binding of the command, as the user set it or by
+// default
+func (k *keymap) binding(cmd *command) string {
+	if binding, ok := k.loadOverrides()[cmd.id]; ok {
+		return binding
+	}
+	return cmd.defaultKey
+}
+
+// load builds the bindings from the defaults and the saved overrides
+func (k *keymap) load() {
+	overrides := k.loadOverrides()
+	k.bindings = make(map[keyCombo]*command)
+	for _, cmd := range k.commands {
+		binding := cmd.defaultKey
+		if override, ok := overrides[cmd.id]; ok {
+			binding = override
+		}
+		if binding == "" {
+			continue
+		}
+		combo, err := parseKeyCombo(binding)
+		if err != nil {
+			log.Printf("Ignoring key binding of %s: %v", cmd.id, err)
+			continue
+		}
+		k.bindings[combo] = cmd
+	}
+}
+
+// validate checks that binding can be used for cmd
+func (k *keymap) validate(cmd *command, binding string) error {
+	if binding == "" {
+		return nil
+	}
+	combo, err := parseKeyCombo(binding)
+	if err != nil {
+		return err
+	}
+	if other, ok := k.bindings[combo]; ok && other != cmd {
+		return fmt.Errorf("%s is used by %q", combo, other.title)
+	}
+	return nil
+}
+
+// setBinding saves a new binding for the command and applies it. An empty
+// binding unbinds the command
+func (k *keymap) setBinding(cmd *command, binding string) error {
+	if err := k.validate(cmd, binding); err != nil {
+		return err
+	}
+
+	overrides := k.loadOverrides()
+	if binding == cmd.defaultKey {
+		delete(overrides, cmd.id)
+	} else {
+		overrides[cmd.id] = binding
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+	k.prefs.SetString(keyBindingsPreferenceKey, string(data))
+
+	k.load()
+	k.register(k.workspace.window.Canvas())
+	return nil
+}
+
+// register installs the bindings on canvas, replacing earlier ones
+func (k *keymap) register(canvas fyne.Canvas) {
+	for _, shortcut := range k.registered {
+		canvas.RemoveShortcut(shortcut)
+	}
+	k.registered = k.registered[:0]
+
+	for combo := range k.bindings {
+		if !combo.usesCanvasShortcut() {
+			continue
+		}
+		shortcut := &desktop.CustomShortcut{KeyName: combo.key, Modifier: combo.modifier}
+		canvas.AddShortcut(shortcut, func(fyne.Shortcut) { k.run(combo) })
+		k.registered = append(k.registered, shortcut)
+	}
+
+	canvas.SetOnTypedKey(func(event *fyne.KeyEvent) { k.typedKey(event) })
+	if deskCanvas, ok := canvas.(desktop.Canvas); ok {
+		deskCanvas.SetOnKeyDown(func(event *fyne.KeyEvent) { k.trackShift(event, true) })
+		deskCanvas.SetOnKeyUp(func(event *fyne.KeyEvent) { k.trackShift(event, false) })
+	}
+}
+
+func (k *keymap) trackShift(event *fyne.KeyEvent, down bool) {
+	if event.Name == desktop.KeyShiftLeft || event.Name == desktop.KeyShiftRight {
+		k.shift = down
+	}
+}
+
+// typedKey runs the command bound to a key without modifiers or with Shift
+func (k *keymap) typedKey(event *fyne.KeyEvent) bool {
+	combo := keyCombo{key: event.Name}
+	if k.shift {
+		combo.modifier = fyne.KeyModifierShift
+	}
+	return k.run(combo)
+}
+
+// typedShortcut runs the command bound to a shortcut typed into a focused
+// entry, which would otherwise swallow it
+func (k *keymap) typedShortcut(shortcut fyne.Shortcut) bool {
+	custom, ok := shortcut.(*desktop.CustomShortcut)
+	if !ok {
+		return false
+	}
+	return k.run(keyCombo{modifier: custom.Modifier, key: custom.KeyName})
+}
+
+// typedFunctionKey forwards function keys typed into a focused entry
+func (k *keymap) typedFunctionKey(event *fyne.KeyEvent) bool {
+	if !strings.HasPrefix(string(event.Name), "F") || len(event.Name) < 2 {
+		return false
+	}
+	return k.typedKey(event)
+}
+
+func (k *keymap) run(combo keyCombo) bool {
+	cmd, ok := k.bindings[combo]
+	if !ok {
+		return false
+	}
+	return k.workspace.runCommand(cmd)
+}
+
+// available returns the commands that can run right now
+func (k *keymap) available() []*command {
+	hasRepository := k.workspace.active() != nil
+	return slices.DeleteFunc(slices.Clone(k.commands), func(cmd *command) bool {
+		return cmd.repository != nil && !hasRepository
+	})
+}
+
+// runCommand runs cmd and reports whether it could run
+func (w *Workspace) runCommand(cmd *command) bool {
+	if cmd.workspace != nil {
+		cmd.workspace(w)
+		return true
+	}
+	repo := w.active()
+	if repo == nil {
+		return false
+	}
+	cmd.repository(repo)
+	return true
+}
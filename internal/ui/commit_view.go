@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// showCommit opens a window with the message and patch of a commit
+func (app *GleamApp) showCommit(commit string) {
+	defer app.logTiming("Commit view")()
+
+	patch, err := app.git.ShowCommit(commit)
+	if err != nil {
+		dialog.ShowError(err, app.ui.window)
+		return
+	}
+
+	window := app.fyneApp.NewWindow("Commit " + shortHash(commit))
+	window.SetContent(newLazyDiffView(patch, false, app.tabWidth, nil))
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
+
+func shortHash(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+	"gleam/internal/task"
+)
+
+const (
+	compareWorkingTree = "(working tree)"
+	compareIndex       = "(index)"
+)
+
+// showCompareWindow opens a window that compares any two revisions, the
+// index or the working tree and shows the changed files with their diffs
+func (app *GleamApp) showCompareWindow() {
+	var refs []git.Ref
+	app.tasks.Go(func(ctx context.Context) error {
+		var err error
+		refs, err = git.WithContext(app.backend, ctx).GetRefs()
+		return err
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+			return
+		}
+		app.openCompareWindow(refs)
+	})
+}
+
+func (app *GleamApp) openCompareWindow(refs []git.Ref) {
+	refNames := []string{"HEAD"}
+	for _, ref := range refs {
+		refNames = append(refNames, ref.Name)
+	}
+
+	window := app.fyneApp.NewWindow("Compare")
+
+	fromEntry := widget.NewSelectEntry(refNames)
+	fromEntry.SetText("HEAD")
+	toEntry := widget.NewSelectEntry(append([]string{compareWorkingTree, compareIndex}, refNames...))
+	toEntry.SetText(compareWorkingTree)
+	mergeBaseCheck := widget.NewCheck("Only changes since merge base", nil)
+	// The merge base needs a revision on both sides
+	toEntry.OnChanged = func(to string) {
+		if to == compareWorkingTree || to == compareIndex {
+			mergeBaseCheck.SetChecked(false)
+			mergeBaseCheck.Disable()
+		} else {
+			mergeBaseCheck.Enable()
+		}
+	}
+	toEntry.OnChanged(toEntry.Text)
+
+	var comparison git.Comparison
+	var changes []git.FileChange
+
+	diffContainer := container.NewStack(NewDiffView(""))
+	summary := widget.NewLabel("")
+
+	fileList := widget.NewList(
+		func() int { return len(changes) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			change := changes[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(fileChangeStats(change))
+			row.Objects[1].(*widget.Label).SetText(fileChangeLabel(change))
+		},
+	)
+	// Showing the diff of a file and a new comparison both replace the diff,
+	// so each cancels the other
+	diffTask := windowTask(window, "compare-diff")
+	fileList.OnSelected = func(id widget.ListItemID) {
+		shown, change := comparison, changes[id]
+		task.Latest(app.tasks, diffTask, func(ctx context.Context) (string, error) {
+			return app.git.WithContext(ctx).CompareFile(shown, change)
+		}, func(diff string, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			diffContainer.Objects[0] = newLazyDiffView(diff, shown.Options.WordDiff, app.tabWidth, nil)
+			diffContainer.Refresh()
+		})
+	}
+
+	compare := func() {
+		requested := git.Comparison{
+			From:      fromEntry.Text,
+			MergeBase: mergeBaseCheck.Checked,
+			Options:   app.state.diffOptions,
+		}
+		switch toEntry.Text {
+		case compareWorkingTree:
+		case compareIndex:
+			requested.Cached = true
+		default:
+			requested.To = toEntry.Text
+		}
+
+		task.Latest(app.tasks, diffTask, func(ctx context.Context) ([]git.FileChange, error) {
+			return app.git.WithContext(ctx).ChangedFiles(requested)
+		}, func(result []git.FileChange, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+
+			comparison, changes = requested, result
+			additions, deletions := 0, 0
+			for _, change := range changes {
+				additions += change.Additions
+				deletions += change.Deletions
+			}
+			summary.SetText(fmt.Sprintf("%d files changed, %d insertions(+), %d deletions(-)", len(changes), additions, deletions))
+
+			fileList.UnselectAll()
+			fileList.Refresh()
+			diffContainer.Objects[0] = NewDiffView("")
+			diffContainer.Refresh()
+		})
+	}
+
+	compareButton := widget.NewButton("Compare", compare)
+	compareButton.Importance = widget.HighImportance
+
+	form := container.New(
+		layout.NewFormLayout(),
+		widget.NewLabel("From"), fromEntry,
+		widget.NewLabel("To"), toEntry,
+	)
+	controls := container.NewVBox(form, container.NewHBox(mergeBaseCheck, compareButton))
+
+	split := container.NewHSplit(container.NewBorder(nil, summary, nil, nil, fileList), diffContainer)
+	split.Offset = 0.3
+
+	window.SetContent(container.NewBorder(controls, nil, nil, nil, split))
+	window.Resize(fyne.NewSize(1100, 650))
+	window.Show()
+
+	compare()
+}
+
+func fileChangeStats(change git.FileChange) string {
+	if change.Binary {
+		return fmt.Sprintf("%s  binary  ", change.Status)
+	}
+	return fmt.Sprintf("%s %+5d %-5s", change.Status, change.Additions, fmt.Sprintf("-%d", change.Deletions))
+}
+
+func fileChangeLabel(change git.FileChange) string {
+	if change.OldPath != "" && change.OldPath != change.Path {
+		return change.OldPath + " → " + change.Path
+	}
+	return change.Path
+}
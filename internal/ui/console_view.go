@@ -0,0 +1,366 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// maxInvocations bounds how many git runs the console keeps
+const maxInvocations = 1000
+
+// operationLog keeps the latest git invocations of a repository. It is
+// filled from the goroutines that run git
+type operationLog struct {
+	mutex    sync.Mutex
+	entries  []git.Invocation
+	onChange func()
+}
+
+func (l *operationLog) record(invocation git.Invocation) {
+	l.mutex.Lock()
+	l.entries = append(l.entries, invocation)
+	if excess := len(l.entries) - maxInvocations; excess > 0 {
+		l.entries = slices.Delete(l.entries, 0, excess)
+	}
+	onChange := l.onChange
+	l.mutex.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// filtered returns the invocations whose command line or stderr contains
+// filter, optionally only the failed ones
+func (l *operationLog) filtered(filter string, failedOnly bool) []git.Invocation {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	filter = strings.ToLower(filter)
+	return slices.DeleteFunc(slices.Clone(l.entries), func(invocation git.Invocation) bool {
+		if failedOnly && invocation.Err == nil {
+			return true
+		}
+		text := strings.ToLower(commandLine(invocation.Args) + "\n" + invocation.Stderr)
+		return !strings.Contains(text, filter)
+	})
+}
+
+func (l *operationLog) clear() {
+	l.mutex.Lock()
+	l.entries = nil
+	onChange := l.onChange
+	l.mutex.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// commandLine shows git arguments as they would be typed in a shell
+func commandLine(args []string) string {
+	quoted := make([]string, len(args)+1)
+	quoted[0] = "git"
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i+1] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitCommandLine splits a command typed into the console into arguments,
+// honoring single and double quotes and backslash escapes. A leading "git"
+// is dropped
+func splitCommandLine(line string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	if len(args) > 0 && args[0] == "git" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, errors.New("no git command given")
+	}
+	return args, nil
+}
+
+func invocationSummary(invocation git.Invocation) string {
+	return fmt.Sprintf("%s  %s  ·  exit %d  ·  %s",
+		invocation.Start.Format("15:04:05"),
+		commandLine(invocation.Args),
+		invocation.ExitCode,
+		invocation.Duration.Round(time.Millisecond))
+}
+
+// describeInvocation shows everything recorded about a run of git
+func describeInvocation(invocation git.Invocation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ %s\n", commandLine(invocation.Args))
+	fmt.Fprintf(&b, "Directory: %s\n", invocation.Dir)
+	fmt.Fprintf(&b, "Started:   %s\n", invocation.Start.Format("2006-01-02 15:04:05.000"))
+	fmt.Fprintf(&b, "Duration:  %s\n", invocation.Duration.Round(time.Microsecond))
+	fmt.Fprintf(&b, "Exit code: %d\n", invocation.ExitCode)
+	if invocation.Err != nil {
+		fmt.Fprintf(&b, "Error:     %v\n", invocation.Err)
+	}
+	fmt.Fprintf(&b, "Stdout:    %s\n", formatFileSize(int64(invocation.StdoutBytes)))
+	fmt.Fprintf(&b, "Stderr:    %s\n", formatFileSize(int64(len(invocation.Stderr))))
+	if invocation.Stderr != "" {
+		b.WriteString("\n" + strings.TrimRight(invocation.Stderr, "\n") + "\n")
+	}
+	return b.String()
+}
+
+// consolePanel returns the git console of the repository, creating it on
+// first use. It lists every git invocation with a filter, the details of
+// the selected one and a box to run git commands
+func (app *GleamApp) consolePanel() fyne.CanvasObject {
+	console := &app.ui.console
+	if console.panel != nil {
+		return console.panel
+	}
+
+	filter := widget.NewEntry()
+	filter.SetPlaceHolder("Filter commands and errors")
+	failedOnly := widget.NewCheck("Failed only", nil)
+
+	details := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	detailsScroll := container.NewScroll(details)
+
+	var visible []git.Invocation
+	selected := -1
+	list := widget.NewList(
+		func() int {
+			visible = app.operations.filtered(filter.Text, failedOnly.Checked)
+			return len(visible)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewIcon(nil), nil, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id >= len(visible) {
+				return
+			}
+			row := item.(*fyne.Container)
+			invocation := visible[id]
+			icon := theme.ConfirmIcon()
+			if invocation.Err != nil {
+				icon = theme.ErrorIcon()
+			}
+			row.Objects[1].(*widget.Icon).SetResource(icon)
+			row.Objects[0].(*widget.Label).SetText(invocationSummary(invocation))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id < len(visible) {
+			selected = id
+			details.SetText(describeInvocation(visible[id]))
+			detailsScroll.ScrollToTop()
+		}
+	}
+	refilter := func() {
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+	}
+	filter.OnChanged = func(string) { refilter() }
+	failedOnly.OnChanged = func(bool) { refilter() }
+
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		text := details.Text
+		if selected < 0 {
+			descriptions := make([]string, len(visible))
+			for i, invocation := range visible {
+				descriptions[i] = describeInvocation(invocation)
+			}
+			text = strings.Join(descriptions, "\n")
+		}
+		app.ui.window.Clipboard().SetContent(text)
+	})
+	clearButton := widget.NewButtonWithIcon("Clear", theme.DeleteIcon(), func() {
+		app.operations.clear()
+		details.SetText("")
+		refilter()
+	})
+	console.dockButton = widget.NewButtonWithIcon("Pop out", theme.ViewFullScreenIcon(), app.toggleConsoleWindow)
+
+	commandEntry := newShortcutEntry(app.keymap, false)
+	commandEntry.SetPlaceHolder("Run a git command, for example log --oneline -5")
+	run := func() {
+		args, err := splitCommandLine(commandEntry.Text)
+		if err != nil {
+			details.SetText(err.Error())
+			return
+		}
+		app.runConsoleCommand(args, func(output string) {
+			details.SetText(output)
+			detailsScroll.ScrollToTop()
+		})
+		commandEntry.SetText("")
+	}
+	commandEntry.OnSubmitted = func(string) { run() }
+	runButton := widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), run)
+
+	app.operations.mutex.Lock()
+	app.operations.onChange = func() {
+		list.Refresh()
+		if selected < 0 {
+			list.ScrollToBottom()
+		}
+	}
+	app.operations.mutex.Unlock()
+
+	header := container.NewBorder(nil, nil, nil,
+		container.NewHBox(failedOnly, copyButton, clearButton, console.dockButton),
+		filter,
+	)
+	runBar := container.NewBorder(nil, nil, widget.NewLabel("git"), runButton, commandEntry)
+	split := container.NewHSplit(list, detailsScroll)
+	split.Offset = 0.55
+
+	console.panel = container.NewBorder(header, runBar, nil, nil, split)
+	return console.panel
+}
+
+// undoableConsoleCommands are the git commands typed into the console that
+// are recorded for undo, with the snapshot taken before them. The window has
+// no buttons for amending, merging or rebasing, so this is how those are
+// undone. commit covers --amend and leaves the working tree alone
+var undoableConsoleCommands = map[string]git.SnapshotOptions{
+	"commit":      {},
+	"merge":       {WorkTree: true},
+	"rebase":      {WorkTree: true},
+	"cherry-pick": {WorkTree: true},
+	"revert":      {WorkTree: true},
+	"pull":        {WorkTree: true},
+}
+
+// runConsoleCommand runs a git command typed into the console and shows its
+// output. The command may change the repository, so the file list and the
+// diff are reloaded afterwards
+func (app *GleamApp) runConsoleCommand(args []string, show func(output string)) {
+	var stdout, stderr string
+	app.tasks.Serial(func(ctx context.Context) error {
+		if opts, ok := undoableConsoleCommands[args[0]]; ok {
+			if err := app.recordUndo("“"+commandLine(args)+"”", opts); err != nil {
+				return err
+			}
+		}
+		var err error
+		stdout, stderr, err = app.git.WithContext(ctx).Run(args...)
+		return err
+	}, func(err error) {
+		output := []string{"$ " + commandLine(args)}
+		for _, text := range []string{stdout, stderr} {
+			if text = strings.TrimRight(text, "\n"); text != "" {
+				output = append(output, text)
+			}
+		}
+		if err != nil {
+			output = append(output, err.Error())
+		}
+		show(strings.Join(output, "\n\n"))
+		app.refreshFileList()
+		app.refreshDiffView()
+	})
+}
+
+// toggleConsole shows or hides the console docked below the diff, or brings
+// its window to the front if it was popped out
+func (app *GleamApp) toggleConsole() {
+	console := &app.ui.console
+	switch {
+	case console.window != nil:
+		console.window.RequestFocus()
+	default:
+		app.dockConsole(!console.docked)
+	}
+}
+
+func (app *GleamApp) dockConsole(show bool) {
+	console := &app.ui.console
+	console.docked = show
+	if !show {
+		console.dock.Objects = []fyne.CanvasObject{console.main}
+		console.dock.Refresh()
+		return
+	}
+
+	split := container.NewVSplit(console.main, app.consolePanel())
+	split.Offset = 0.7
+	console.dock.Objects = []fyne.CanvasObject{split}
+	console.dock.Refresh()
+	console.dockButton.SetText("Pop out")
+}
+
+// toggleConsoleWindow moves the console between the repository window and a
+// window of its own
+func (app *GleamApp) toggleConsoleWindow() {
+	console := &app.ui.console
+	if console.window != nil {
+		// Closing the window docks the console again
+		console.window.Close()
+		return
+	}
+
+	app.dockConsole(false)
+	window := app.fyneApp.NewWindow("Git console — " + app.Name())
+	window.SetContent(app.consolePanel())
+	window.SetOnClosed(func() {
+		console.window = nil
+		app.dockConsole(true)
+	})
+	window.Resize(fyne.NewSize(900, 500))
+	console.window = window
+	console.dockButton.SetText("Dock")
+	window.Show()
+}
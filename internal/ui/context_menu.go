@@ -4,37 +4,70 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
 )
 
 func (app *GleamApp) MouseDown(event *desktop.MouseEvent) {
 	println("mos")
 	if event.Button == desktop.MouseButtonSecondary {
-		app.showPopupMenu(event.AbsolutePosition)
+		app.showPopupMenu(event.AbsolutePosition, app.state.activeFileDiff)
 	}
 }
 
-func (app *GleamApp) showPopupMenu(pos fyne.Position) {
-	if app.ui.popup == nil {
-		menu := fyne.NewMenu("Opts",
-			fyne.NewMenuItem("Discard changes", func() {
-			}),
-		)
+func (app *GleamApp) showPopupMenu(pos fyne.Position, file string) {
+	if app.ui.popup != nil {
+		app.ui.popup.Hide()
+	}
 
-		// menu.Items = append(menu.Items,
-		// 	fyne.NewMenuItem("Paste", func() {
-		// 		mv.paste()
-		// 	}),
-		// 	fyne.NewMenuItem("Smooth", func() {
-		// 		mv.smooth()
-		// 	}),
-		// )
-		popupMenu := widget.NewPopUpMenu(menu,
-			fyne.CurrentApp().Driver().CanvasForObject(app.ui.window.Canvas().Content()),
-		)
+	popupMenu := widget.NewPopUpMenu(app.fileMenu(file),
+		app.fyneApp.Driver().CanvasForObject(app.ui.window.Canvas().Content()),
+	)
+
+	popupMenu.ShowAtPosition(pos)
+	app.ui.popup = popupMenu
+}
 
-		popupMenu.ShowAtPosition(pos)
-		app.ui.popup = popupMenu
-		return
+// fileMenu builds the context menu of a changed file
+func (app *GleamApp) fileMenu(file string) *fyne.Menu {
+	menu := fyne.NewMenu("Opts",
+		fyne.NewMenuItem("Discard changes", func() {
+			app.discardChanges(file)
+		}),
+		fyne.NewMenuItem("Show history", func() {
+			app.showFileHistory(file)
+		}),
+	)
+	if app.isLFSFile(file) {
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Untrack from Git LFS...", func() {
+			app.showUntrackLFS(file)
+		}))
+	} else {
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Track with Git LFS...", func() {
+			app.showTrackLFS(file)
+		}))
+	}
+	if _, ok := app.submodule(file); ok {
+		menu.Items = append(menu.Items,
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Open submodule", func() {
+				app.openSubmodule(file)
+			}),
+			fyne.NewMenuItem("Update submodule", func() {
+				app.runSubmoduleOperation(app.ui.window, "Update", func(command *git.GitCommand) error {
+					return command.UpdateSubmodules([]string{file})
+				}, nil)
+			}),
+		)
 	}
-	app.ui.popup.ShowAtPosition(pos)
+
+	// menu.Items = append(menu.Items,
+	// 	fyne.NewMenuItem("Paste", func() {
+	// 		mv.paste()
+	// 	}),
+	// 	fyne.NewMenuItem("Smooth", func() {
+	// 		mv.smooth()
+	// 	}),
+	// )
+	return menu
 }
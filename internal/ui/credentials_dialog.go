@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/askpass"
+	"gleam/internal/git"
+)
+
+// startAskpass routes credential prompts of git and ssh to the window
+func (app *GleamApp) startAskpass() {
+	server, err := askpass.NewServer(app.promptCredentials)
+	if err != nil {
+		log.Printf("Error starting askpass server, credential prompts are disabled: %v", err)
+		return
+	}
+	app.askpass = server
+	app.git.Env = append(app.git.Env, server.Env()...)
+}
+
+func (app *GleamApp) stopAskpass() {
+	if app.askpass != nil {
+		app.askpass.Close()
+		app.askpass = nil
+	}
+}
+
+// promptCredentials asks the user for the answer to a git or ssh prompt. It is
+// called from the askpass server and blocks until the dialog is closed
+func (app *GleamApp) promptCredentials(prompt string) (string, bool) {
+	app.credentials.Lock()
+	cached, ok := app.credentials.cache[prompt]
+	if ok {
+		app.credentials.used[prompt] = true
+	}
+	app.credentials.Unlock()
+	if ok {
+		return cached, true
+	}
+
+	var answerEntry *widget.Entry
+	if isSecretPrompt(prompt) {
+		answerEntry = widget.NewPasswordEntry()
+	} else {
+		answerEntry = widget.NewEntry()
+	}
+	rememberCheck := widget.NewCheck("Remember for this session", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("", widget.NewLabel(strings.TrimSpace(prompt))),
+		widget.NewFormItem("", answerEntry),
+		widget.NewFormItem("", rememberCheck),
+	}
+
+	result := make(chan bool, 1)
+	form := dialog.NewForm("Authentication required", "OK", "Cancel", items, func(ok bool) {
+		result <- ok
+	}, app.ui.window)
+	form.Resize(fyne.NewSize(420, 0))
+	form.Show()
+	app.ui.window.Canvas().Focus(answerEntry)
+
+	if !<-result {
+		return "", false
+	}
+
+	answer := answerEntry.Text
+	if rememberCheck.Checked {
+		app.credentials.Lock()
+		app.credentials.cache[prompt] = answer
+		app.credentials.used[prompt] = true
+		app.credentials.Unlock()
+	}
+	return answer, true
+}
+
+// forgetRejectedCredentials forgets the remembered answers given to git
+// since the last authentication failure if err is one, so that the next
+// attempt asks again. It returns err
+func (app *GleamApp) forgetRejectedCredentials(err error) error {
+	if !errors.Is(err, git.ErrAuthentication) {
+		return err
+	}
+	app.credentials.Lock()
+	for prompt := range app.credentials.used {
+		delete(app.credentials.cache, prompt)
+	}
+	clear(app.credentials.used)
+	app.credentials.Unlock()
+	return err
+}
+
+// forgetCredentials forgets every answer remembered for this session
+func (app *GleamApp) forgetCredentials() {
+	app.credentials.Lock()
+	clear(app.credentials.cache)
+	clear(app.credentials.used)
+	app.credentials.Unlock()
+	dialog.ShowInformation("Credentials", "Saved credentials were forgotten", app.ui.window)
+}
+
+// isSecretPrompt reports whether the answer to prompt should be masked
+func isSecretPrompt(prompt string) bool {
+	lower := strings.ToLower(prompt)
+	return !strings.HasPrefix(lower, "username") && !strings.Contains(lower, "(yes/no")
+}
@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gleam/internal/git"
+)
+
+func TestRejectedCredentialsAreForgotten(t *testing.T) {
+	app := newTestGleamApp(t, git.NewFakeBackend())
+	app.credentials.cache["Password for 'https://example.com': "] = "wrong"
+	app.credentials.cache["Password for 'https://other.com': "] = "right"
+
+	if answer, ok := app.promptCredentials("Password for 'https://example.com': "); !ok || answer != "wrong" {
+		t.Fatalf("remembered answer is %q, %v", answer, ok)
+	}
+	app.forgetRejectedCredentials(errors.New("exit status 128"))
+	if len(app.credentials.cache) != 2 {
+		t.Errorf("an unrelated failure forgot credentials, left %v", app.credentials.cache)
+	}
+
+	err := fmt.Errorf("%w: fatal: Authentication failed", git.ErrAuthentication)
+	if got := app.forgetRejectedCredentials(err); got != err {
+		t.Errorf("forgetRejectedCredentials() = %v, want %v", got, err)
+	}
+	if _, ok := app.credentials.cache["Password for 'https://example.com': "]; ok {
+		t.Errorf("rejected answer is still remembered")
+	}
+	if _, ok := app.credentials.cache["Password for 'https://other.com': "]; !ok {
+		t.Errorf("an answer that was not used was forgotten")
+	}
+}
@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+	"gleam/internal/task"
+)
+
+const (
+	dashboardRootsPreferenceKey = "dashboardRoots"
+
+	// dashboardWorkers bounds how many git processes the dashboard runs at
+	// once
+	dashboardWorkers = 8
+	dashboardDepth   = 3
+)
+
+var dashboardColumns = []string{"Repository", "Branch", "Changes", "Ahead", "Behind", "Last fetch", "Status"}
+
+// dashboardRow is the state of one repository in the dashboard
+type dashboardRow struct {
+	path    string
+	summary git.Summary
+	loaded  bool
+	message string
+}
+
+// dashboard shows the status of every repository below a set of roots
+type dashboard struct {
+	workspace *Workspace
+	window    fyne.Window
+	roots     []string
+
+	// rowsMutex guards rows and selected, which updates change while the
+	// table and the buttons read them
+	rowsMutex sync.RWMutex
+	rows      []dashboardRow
+	selected  int
+	table     *widget.Table
+	status    *widget.Label
+
+	// tasks runs the scan or bulk action, a new one canceling the last
+	tasks   *task.Scheduler
+	updates *uiUpdates
+}
+
+// showDashboard opens the dashboard window and scans the saved roots
+func (w *Workspace) showDashboard() {
+	d := &dashboard{
+		workspace: w,
+		window:    fyne.CurrentApp().NewWindow("Repositories"),
+		roots:     loadDashboardRoots(),
+		selected:  -1,
+		updates:   newUIUpdates(),
+	}
+	d.tasks = task.NewScheduler(d.updates.run)
+
+	d.window.SetContent(d.createContent())
+	d.window.SetOnClosed(func() {
+		d.updates.close()
+		go d.tasks.Close()
+	})
+	d.window.Resize(fyne.NewSize(1000, 600))
+	d.window.Show()
+	d.scan()
+}
+
+func (d *dashboard) createContent() fyne.CanvasObject {
+	rootList := widget.NewList(
+		func() int { return len(d.roots) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(d.roots[id])
+		},
+	)
+	selectedRoot := -1
+	rootList.OnSelected = func(id widget.ListItemID) { selectedRoot = id }
+
+	addRoot := widget.NewButtonWithIcon("Add root…", theme.ContentAddIcon(), func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil || folder == nil || slices.Contains(d.roots, folder.Path()) {
+				return
+			}
+			d.roots = append(d.roots, folder.Path())
+			saveDashboardRoots(d.roots)
+			rootList.Refresh()
+			d.scan()
+		}, d.window)
+	})
+	removeRoot := widget.NewButtonWithIcon("Remove", theme.ContentRemoveIcon(), func() {
+		if selectedRoot < 0 || selectedRoot >= len(d.roots) {
+			return
+		}
+		d.roots = slices.Delete(d.roots, selectedRoot, selectedRoot+1)
+		saveDashboardRoots(d.roots)
+		rootList.UnselectAll()
+		selectedRoot = -1
+		rootList.Refresh()
+		d.scan()
+	})
+	rootPane := container.NewBorder(widget.NewLabel("Roots"), container.NewHBox(addRoot, removeRoot), nil, nil, rootList)
+
+	d.table = widget.NewTable(
+		func() (int, int) {
+			d.rowsMutex.RLock()
+			defer d.rowsMutex.RUnlock()
+			return len(d.rows), len(dashboardColumns)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		d.updateCell,
+	)
+	d.table.ShowHeaderRow = true
+	d.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	d.table.UpdateHeader = func(id widget.TableCellID, item fyne.CanvasObject) {
+		if id.Col >= 0 {
+			item.(*widget.Label).SetText(dashboardColumns[id.Col])
+		}
+	}
+	for col, width := range []float32{260, 140, 80, 70, 70, 140, 220} {
+		d.table.SetColumnWidth(col, width)
+	}
+	d.table.OnSelected = func(id widget.TableCellID) {
+		d.rowsMutex.Lock()
+		d.selected = id.Row
+		d.rowsMutex.Unlock()
+	}
+
+	d.status = widget.NewLabel("")
+	actions := container.NewHBox(
+		widget.NewButtonWithIcon("Rescan", theme.ViewRefreshIcon(), d.scan),
+		widget.NewButtonWithIcon("Fetch all", theme.DownloadIcon(), func() {
+			d.runOnAll("Fetching", func(g *git.GitCommand) error {
+				return g.Fetch(git.FetchOptions{})
+			})
+		}),
+		widget.NewButtonWithIcon("Pull all (fast-forward only)", theme.MoveDownIcon(), func() {
+			d.runOnAll("Pulling", func(g *git.GitCommand) error {
+				return g.Pull(git.PullOptions{FastForwardOnly: true})
+			})
+		}),
+		widget.NewButtonWithIcon("Open in tab", theme.FolderOpenIcon(), d.openSelected),
+	)
+
+	split := container.NewHSplit(rootPane, d.table)
+	split.Offset = 0.22
+	return container.NewBorder(actions, d.status, nil, nil, split)
+}
+
+func (d *dashboard) updateCell(id widget.TableCellID, item fyne.CanvasObject) {
+	label := item.(*widget.Label)
+	d.rowsMutex.RLock()
+	if id.Row >= len(d.rows) {
+		d.rowsMutex.RUnlock()
+		label.SetText("")
+		return
+	}
+	row := d.rows[id.Row]
+	d.rowsMutex.RUnlock()
+	summary := row.summary
+
+	text := ""
+	switch id.Col {
+	case 0:
+		text = filepath.Base(row.path)
+	case 1:
+		text = summary.Branch
+	case 2:
+		text = strconv.Itoa(summary.Dirty)
+	case 3:
+		text = aheadBehindLabel(summary.Ahead, summary)
+	case 4:
+		text = aheadBehindLabel(summary.Behind, summary)
+	case 5:
+		text = "never"
+		if !summary.LastFetch.IsZero() {
+			text = summary.LastFetch.Format("2006-01-02 15:04")
+		}
+	case 6:
+		text = row.message
+	}
+	if !row.loaded && id.Col > 0 && id.Col < 6 {
+		text = "…"
+	}
+	label.SetText(text)
+}
+
+func aheadBehindLabel(count int, summary git.Summary) string {
+	if summary.Upstream == "" {
+		return "–"
+	}
+	return strconv.Itoa(count)
+}
+
+// command returns a git command for path that runs the git binary set in the
+// settings, as the repository tabs do. Credential prompts cannot be shown for
+// many repositories at once, so git fails instead of asking
+func (d *dashboard) command(ctx context.Context, path string) *git.GitCommand {
+	prefs := d.workspace.app.Preferences()
+	settings := mergeSettings(defaultSettings(), loadSettingsLayer(prefs, ""), loadSettingsLayer(prefs, path))
+	command := git.NewGitCommand(path).WithContext(ctx)
+	command.Binary = settings.GitPath
+	command.Env = []string{"GIT_TERMINAL_PROMPT=0"}
+	return command
+}
+
+// scan finds the repositories below the roots and collects their status on
+// the worker pool
+func (d *dashboard) scan() {
+	roots := slices.Clone(d.roots)
+	d.status.SetText("Scanning…")
+
+	task.Latest(d.tasks, "work", func(ctx context.Context) (int, error) {
+		paths := git.FindRepositories(roots, dashboardDepth)
+		rows := make([]dashboardRow, len(paths))
+		for i, path := range paths {
+			rows[i] = dashboardRow{path: path}
+		}
+		d.updates.run(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			d.rowsMutex.Lock()
+			d.rows = rows
+			d.selected = -1
+			d.rowsMutex.Unlock()
+			d.table.UnselectAll()
+			d.table.Refresh()
+		})
+
+		d.collect(ctx, paths, nil)
+		return len(paths), nil
+	}, func(count int, _ error) {
+		d.status.SetText(fmt.Sprintf("%d repositories", count))
+	})
+}
+
+// runOnAll runs action in every repository and then refreshes its status
+func (d *dashboard) runOnAll(verb string, action func(g *git.GitCommand) error) {
+	d.rowsMutex.RLock()
+	paths := make([]string, len(d.rows))
+	for i, row := range d.rows {
+		paths[i] = row.path
+	}
+	d.rowsMutex.RUnlock()
+	d.status.SetText(verb + "…")
+
+	task.Latest(d.tasks, "work", func(ctx context.Context) (int, error) {
+		failed := 0
+		var failedMutex sync.Mutex
+		d.collect(ctx, paths, func(g *git.GitCommand) error {
+			err := action(g)
+			if err != nil {
+				failedMutex.Lock()
+				failed++
+				failedMutex.Unlock()
+			}
+			return err
+		})
+		return failed, nil
+	}, func(failed int, _ error) {
+		d.status.SetText(fmt.Sprintf("%s done, %d of %d failed", verb, failed, len(paths)))
+	})
+}
+
+// collect loads the summary of every path on the worker pool, running action
+// first if it is set
+func (d *dashboard) collect(ctx context.Context, paths []string, action func(g *git.GitCommand) error) {
+	task.ForEach(ctx, paths, dashboardWorkers, func(ctx context.Context, path string) {
+		g := d.command(ctx, path)
+		message := ""
+		if action != nil {
+			if err := action(g); err != nil {
+				message = err.Error()
+			} else {
+				message = "Done at " + time.Now().Format("15:04:05")
+			}
+		}
+
+		summary, err := g.Summary()
+		if err != nil {
+			message = err.Error()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		d.updates.run(func() {
+			d.rowsMutex.Lock()
+			index := slices.IndexFunc(d.rows, func(row dashboardRow) bool { return row.path == path })
+			if index >= 0 {
+				d.rows[index] = dashboardRow{path: path, summary: summary, loaded: true, message: message}
+			}
+			d.rowsMutex.Unlock()
+			if index < 0 {
+				return
+			}
+			d.table.Refresh()
+		})
+	})
+}
+
+func (d *dashboard) openSelected() {
+	d.rowsMutex.RLock()
+	path := ""
+	if d.selected >= 0 && d.selected < len(d.rows) {
+		path = d.rows[d.selected].path
+	}
+	d.rowsMutex.RUnlock()
+	if path == "" {
+		dialog.ShowInformation("Open in tab", "Select a repository first.", d.window)
+		return
+	}
+	d.workspace.openOrShowError(path)
+	d.workspace.window.RequestFocus()
+}
+
+func loadDashboardRoots() []string {
+	roots := make([]string, 0)
+	saved := fyne.CurrentApp().Preferences().String(dashboardRootsPreferenceKey)
+	if saved == "" {
+		return roots
+	}
+	if err := json.Unmarshal([]byte(saved), &roots); err != nil {
+		log.Printf("Error loading dashboard roots: %v", err)
+	}
+	return roots
+}
+
+func saveDashboardRoots(roots []string) {
+	data, err := json.Marshal(roots)
+	if err != nil {
+		log.Printf("Error saving dashboard roots: %v", err)
+		return
+	}
+	fyne.CurrentApp().Preferences().SetString(dashboardRootsPreferenceKey, string(data))
+}
@@ -6,53 +6,97 @@ import (
 	"os"
 	"strings"
 
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/lexers"
-	"github.com/alecthomas/chroma/v2/styles"
+
+	"gleam/internal/git"
 )
 
-func highlightDiff(content string) *widget.TextGrid {
-	if content == "" {
-		return widget.NewTextGrid()
-	}
+// wordSpan is a removed or added run of words in a line of a word diff, in
+// bytes of the line without its markers
+type wordSpan struct {
+	start, end int
+	added      bool
+}
 
-	grid := widget.NewTextGridFromString(content)
-	grid.ShowLineNumbers = true
-	style := styles.Get("monokai")
-	lexer := lexers.Get("go")
-
-	lines := strings.Split(content, "\n")
-	for row, line := range lines {
-		line = strings.TrimRight(line, "\r\n")
-		var bgColor color.Color = color.Transparent
-		overlayAlpha := uint8(160)
-
-		// Determine line type and background color
-		switch {
-		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
-			bgColor = color.NRGBA{R: 66, G: 133, B: 244, A: overlayAlpha}
-			setLineStyle(grid, row, line, bgColor, color.White)
-			continue
-		case strings.HasPrefix(line, "@@"):
-			bgColor = color.NRGBA{R: 66, G: 133, B: 244, A: overlayAlpha}
-			setLineStyle(grid, row, line, bgColor, color.White)
-			continue
-		case strings.HasPrefix(line, "+"):
-			bgColor = color.NRGBA{R: 46, G: 160, B: 46, A: overlayAlpha} // green
-		case strings.HasPrefix(line, "-"):
-			bgColor = color.NRGBA{R: 203, G: 54, B: 53, A: overlayAlpha} // red
+// parseWordDiff removes the markers of the changed words from line and
+// returns the spans they enclosed
+func parseWordDiff(line string) (string, []wordSpan) {
+	var text strings.Builder
+	spans := make([]wordSpan, 0)
+	last := 0
+	for _, match := range git.WordDiffPattern.FindAllStringSubmatchIndex(line, -1) {
+		text.WriteString(line[last:match[0]])
+		span := wordSpan{start: text.Len()}
+		if match[2] >= 0 {
+			text.WriteString(line[match[2]:match[3]])
+		} else {
+			text.WriteString(line[match[4]:match[5]])
+			span.added = true
 		}
+		span.end = text.Len()
+		spans = append(spans, span)
+		last = match[1]
+	}
+	text.WriteString(line[last:])
+	return text.String(), spans
+}
 
-		if bgColor != color.Transparent {
-			handleDiffLine(grid, row, line, bgColor, lexer, style)
-		} else {
-			handleRegularLine(grid, row, line, lexer, style)
+// highlightWordSpans marks the removed and added words of a word diff line
+// in grid, on top of its syntax highlighting
+func highlightWordSpans(grid *widget.TextGrid, row int, line string, spans []wordSpan, theme *diffTheme, tabWidth int) {
+	for _, span := range spans {
+		bg := theme.palette.Removed
+		if span.added {
+			bg = theme.palette.Added
+		}
+		_, start := expandTabs(0, line[:span.start], tabWidth)
+		_, end := expandTabs(start, line[span.start:span.end], tabWidth)
+		cells := grid.Rows[row].Cells
+		for col := start; col < min(end, len(cells)); col++ {
+			// Keep the syntax color of the words
+			var fg color.Color
+			if cells[col].Style != nil {
+				fg = cells[col].Style.TextColor()
+			}
+			grid.SetStyle(row, col, &widget.CustomTextGridStyle{BGColor: bg, FGColor: fg})
 		}
 	}
+}
+
+// highlightDiffLine styles one row of grid by its diff kind and syntax
+func highlightDiffLine(grid *widget.TextGrid, row int, line string, kind git.DiffLineKind, lexer chroma.Lexer, theme *diffTheme, tabWidth int) {
+	line = strings.TrimRight(line, "\r\n")
+	palette := theme.palette
+
+	switch kind {
+	case git.DiffLineHeader:
+		setLineStyle(grid, row, line, palette.Header, palette.HeaderText)
+	case git.DiffLineHunk:
+		setLineStyle(grid, row, line, palette.Hunk, palette.HeaderText)
+	case git.DiffLineAdded:
+		handleDiffLine(grid, row, line, palette.Added, palette.Marker, lexer, theme.style, tabWidth)
+	case git.DiffLineRemoved:
+		handleDiffLine(grid, row, line, palette.Removed, palette.Marker, lexer, theme.style, tabWidth)
+	default:
+		handleRegularLine(grid, row, line, lexer, theme.style, tabWidth)
+	}
+}
 
-	//grid.Refresh()
-	return grid
+// lexerForDiff picks a syntax lexer from the first file name in a diff
+func lexerForDiff(lines []string) chroma.Lexer {
+	for _, line := range lines {
+		if path, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			if lexer := lexers.Match(path); lexer != nil {
+				return chroma.Coalesce(lexer)
+			}
+			break
+		}
+	}
+	return lexers.Get("go")
 }
 
 func setLineStyle(grid *widget.TextGrid, row int, _ string, bg, fg color.Color) {
@@ -62,7 +106,7 @@ func setLineStyle(grid *widget.TextGrid, row int, _ string, bg, fg color.Color)
 	})
 }
 
-func handleDiffLine(grid *widget.TextGrid, row int, line string, bg color.Color, lexer chroma.Lexer, style *chroma.Style) {
+func handleDiffLine(grid *widget.TextGrid, row int, line string, bg, marker color.Color, lexer chroma.Lexer, style *chroma.Style, tabWidth int) {
 	// Set background for entire line
 	setLineStyle(grid, row, line, bg, color.Transparent)
 
@@ -70,7 +114,7 @@ func handleDiffLine(grid *widget.TextGrid, row int, line string, bg color.Color,
 	if len(line) > 0 {
 		grid.SetStyleRange(row, 0, row, 1, &widget.CustomTextGridStyle{
 			BGColor: bg,
-			FGColor: color.NRGBA{R: 255, G: 255, B: 255, A: 200},
+			FGColor: marker,
 		})
 	}
 
@@ -86,7 +130,7 @@ func handleDiffLine(grid *widget.TextGrid, row int, line string, bg color.Color,
 	for _, token := range iterator.Tokens() {
 		entry := style.Get(token.Type)
 		fgColor := resolveColor(entry.Colour)
-		start, end := expandTabs(currentCol, token.Value)
+		start, end := expandTabs(currentCol, token.Value, tabWidth)
 
 		grid.SetStyleRange(row, start, row, end, &widget.CustomTextGridStyle{
 			BGColor: bg,
@@ -97,14 +141,14 @@ func handleDiffLine(grid *widget.TextGrid, row int, line string, bg color.Color,
 	}
 }
 
-func handleRegularLine(grid *widget.TextGrid, row int, line string, lexer chroma.Lexer, style *chroma.Style) {
+func handleRegularLine(grid *widget.TextGrid, row int, line string, lexer chroma.Lexer, style *chroma.Style, tabWidth int) {
 	iterator, _ := lexer.Tokenise(nil, line)
 	currentCol := 0
 
 	for _, token := range iterator.Tokens() {
 		entry := style.Get(token.Type)
 		fgColor := resolveColor(entry.Colour)
-		start, end := expandTabs(currentCol, token.Value)
+		start, end := expandTabs(currentCol, token.Value, tabWidth)
 
 		grid.SetStyleRange(row, start, row, end, &widget.CustomTextGridStyle{
 			BGColor: color.Transparent,
@@ -115,11 +159,13 @@ func handleRegularLine(grid *widget.TextGrid, row int, line string, lexer chroma
 	}
 }
 
-func expandTabs(start int, value string) (int, int) {
+// expandTabs returns the grid columns value spans when it starts at column
+// start, with tabs advancing to the next multiple of width
+func expandTabs(start int, value string, width int) (int, int) {
 	current := start
 	for _, char := range value {
 		if char == '\t' {
-			current += 4 - (current % 4)
+			current += width - (current % width)
 		} else {
 			current++
 		}
@@ -143,3 +189,32 @@ func loadDiffContent(filePath string) (string, error) {
 	}
 	return string(data), nil
 }
+
+// binaryDiffSummary describes a changed binary file instead of showing its
+// raw content
+func binaryDiffSummary(diff git.FileDiff) fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("Binary file changed", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	return container.NewVBox(
+		title,
+		widget.NewLabel(diff.Path),
+		widget.NewLabel("Old size: "+formatFileSize(diff.OldSize)),
+		widget.NewLabel("New size: "+formatFileSize(diff.NewSize)),
+	)
+}
+
+func formatFileSize(size int64) string {
+	if size < 0 {
+		return "(none)"
+	}
+
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB (%d bytes)", value, "KMGT"[exp], size)
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// findEntry is the search field of the find bar. Enter moves to the next
+// match and Escape closes the bar
+type findEntry struct {
+	shortcutEntry
+	onEscape func()
+}
+
+func newFindEntry(keymap *keymap) *findEntry {
+	entry := &findEntry{shortcutEntry: shortcutEntry{keymap: keymap}}
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+func (e *findEntry) TypedKey(key *fyne.KeyEvent) {
+	if key.Name == fyne.KeyEscape && e.onEscape != nil {
+		e.onEscape()
+		return
+	}
+	e.shortcutEntry.TypedKey(key)
+}
+
+// createFindBar builds the hidden find bar of the diff pane
+func (app *GleamApp) createFindBar() fyne.CanvasObject {
+	entry := newFindEntry(app.keymap)
+	entry.SetPlaceHolder("Find in diff")
+	regexCheck := widget.NewCheck("Regex", nil)
+	caseCheck := widget.NewCheck("Match case", nil)
+	countLabel := widget.NewLabel("")
+
+	app.ui.find.entry = entry
+	app.ui.find.regex = regexCheck
+	app.ui.find.matchCase = caseCheck
+	app.ui.find.count = countLabel
+
+	entry.OnChanged = func(string) { app.applySearch() }
+	entry.OnSubmitted = func(string) { app.nextMatch(1) }
+	entry.onEscape = app.hideFindBar
+	regexCheck.OnChanged = func(bool) { app.applySearch() }
+	caseCheck.OnChanged = func(bool) { app.applySearch() }
+
+	previousButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { app.nextMatch(-1) })
+	nextButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { app.nextMatch(1) })
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), app.hideFindBar)
+
+	bar := container.NewBorder(nil, nil, nil,
+		container.NewHBox(regexCheck, caseCheck, countLabel, previousButton, nextButton, closeButton),
+		entry,
+	)
+	bar.Hide()
+	app.ui.find.bar = bar
+	return bar
+}
+
+func (app *GleamApp) showFindBar() {
+	app.ui.find.bar.Show()
+	app.ui.window.Canvas().Focus(app.ui.find.entry)
+	app.applySearch()
+}
+
+func (app *GleamApp) hideFindBar() {
+	app.ui.find.bar.Hide()
+	app.ui.find.count.SetText("")
+	if app.ui.diffViewer != nil {
+		app.ui.diffViewer.ClearSearch()
+	}
+}
+
+// applySearch runs the search of the find bar on the diff that is shown
+func (app *GleamApp) applySearch() {
+	view := app.ui.diffViewer
+	if view == nil || app.ui.find.bar.Hidden {
+		return
+	}
+
+	count, err := view.Search(app.ui.find.entry.Text, app.ui.find.regex.Checked, app.ui.find.matchCase.Checked)
+	if err != nil {
+		app.ui.find.count.SetText("Invalid pattern")
+		return
+	}
+	app.updateMatchCount(-1, count)
+}
+
+func (app *GleamApp) nextMatch(direction int) {
+	view := app.ui.diffViewer
+	if view == nil {
+		return
+	}
+
+	match := view.PreviousMatch
+	if direction > 0 {
+		match = view.NextMatch
+	}
+	app.updateMatchCount(match(), view.MatchCount())
+}
+
+func (app *GleamApp) updateMatchCount(current, count int) {
+	switch {
+	case app.ui.find.entry.Text == "":
+		app.ui.find.count.SetText("")
+	case count == 0:
+		app.ui.find.count.SetText("No matches")
+	case current < 0:
+		app.ui.find.count.SetText(fmt.Sprintf("%d matches", count))
+	default:
+		app.ui.find.count.SetText(fmt.Sprintf("%d of %d", current+1, count))
+	}
+}
+
+// createDiffNavigation returns the buttons that move between hunks and
+// changes of the diff pane
+func (app *GleamApp) createDiffNavigation() fyne.CanvasObject {
+	button := func(icon fyne.Resource, move func(*DiffView) bool) *widget.Button {
+		return widget.NewButtonWithIcon("", icon, func() { app.moveInDiff(move) })
+	}
+	return container.NewHBox(
+		button(theme.MoveUpIcon(), (*DiffView).PreviousHunk),
+		button(theme.MoveDownIcon(), (*DiffView).NextHunk),
+		button(theme.NavigateBackIcon(), (*DiffView).PreviousChange),
+		button(theme.NavigateNextIcon(), (*DiffView).NextChange),
+	)
+}
+
+func (app *GleamApp) moveInDiff(move func(*DiffView) bool) {
+	if app.ui.diffViewer != nil {
+		move(app.ui.diffViewer)
+	}
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"image/color"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+const minimapWidth = 14
+
+// diffMinimap is an overview ruler next to a DiffView. It marks where the
+// additions, deletions and search matches are, and scrolls the view to the
+// position that is tapped
+type diffMinimap struct {
+	widget.BaseWidget
+	view *DiffView
+}
+
+func newDiffMinimap(view *DiffView) *diffMinimap {
+	minimap := &diffMinimap{view: view}
+	minimap.ExtendBaseWidget(minimap)
+	return minimap
+}
+
+func (m *diffMinimap) Tapped(event *fyne.PointEvent) {
+	rows := len(m.view.rows)
+	height := m.Size().Height
+	if rows == 0 || height <= 0 {
+		return
+	}
+	m.view.scrollToRow(int(event.Position.Y / height * float32(rows)))
+}
+
+func (m *diffMinimap) CreateRenderer() fyne.WidgetRenderer {
+	background := canvas.NewRectangle(theme.InputBackgroundColor())
+	return &diffMinimapRenderer{minimap: m, background: background}
+}
+
+// minimapMark is a run of consecutive rows drawn as one rectangle
+type minimapMark struct {
+	start, end int
+	color      color.Color
+}
+
+type diffMinimapRenderer struct {
+	minimap    *diffMinimap
+	background *canvas.Rectangle
+	marks      []minimapMark
+	objects    []fyne.CanvasObject
+}
+
+func (r *diffMinimapRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(minimapWidth, 0)
+}
+
+func (r *diffMinimapRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+
+	rows := len(r.minimap.view.rows)
+	if rows == 0 {
+		return
+	}
+	rowHeight := size.Height / float32(rows)
+	for i, mark := range r.marks {
+		rect := r.objects[i+1]
+		rect.Move(fyne.NewPos(0, float32(mark.start)*rowHeight))
+		rect.Resize(fyne.NewSize(size.Width, max(float32(mark.end-mark.start)*rowHeight, 2)))
+	}
+}
+
+func (r *diffMinimapRenderer) Refresh() {
+	r.background.FillColor = theme.InputBackgroundColor()
+	r.marks = r.collectMarks()
+
+	r.objects = make([]fyne.CanvasObject, 0, len(r.marks)+1)
+	r.objects = append(r.objects, r.background)
+	for _, mark := range r.marks {
+		r.objects = append(r.objects, canvas.NewRectangle(mark.color))
+	}
+
+	r.Layout(r.minimap.Size())
+	canvas.Refresh(r.minimap)
+}
+
+// collectMarks merges consecutive rows of the same kind. Search matches are
+// drawn on top of the line kinds
+func (r *diffMinimapRenderer) collectMarks() []minimapMark {
+	view := r.minimap.view
+	palette := view.theme.palette
+	marks := make([]minimapMark, 0)
+
+	add := func(row int, c color.Color) {
+		if n := len(marks); n > 0 && marks[n-1].end == row && marks[n-1].color == c {
+			marks[n-1].end = row + 1
+			return
+		}
+		marks = append(marks, minimapMark{start: row, end: row + 1, color: c})
+	}
+
+	for id, row := range view.rows {
+		if row.collapsed() {
+			continue
+		}
+		switch view.kinds[row.line] {
+		case git.DiffLineAdded:
+			add(id, palette.Added)
+		case git.DiffLineRemoved:
+			add(id, palette.Removed)
+		case git.DiffLineWordChange:
+			add(id, wordChangeColor(view.words[row.line], palette.Added, palette.Removed, palette.Hunk))
+		}
+	}
+
+	lastRow := -1
+	for _, match := range view.matches {
+		row := view.rowOfLine[match.line]
+		if row != lastRow {
+			add(row, palette.SearchMatch)
+			lastRow = row
+		}
+	}
+	return marks
+}
+
+// wordChangeColor marks a word diff line as added or removed if it only has
+// added or removed words, and as changed if it has both
+func wordChangeColor(spans []wordSpan, added, removed, changed color.Color) color.Color {
+	hasAdded := slices.ContainsFunc(spans, func(span wordSpan) bool { return span.added })
+	hasRemoved := slices.ContainsFunc(spans, func(span wordSpan) bool { return !span.added })
+	switch {
+	case hasAdded && hasRemoved:
+		return changed
+	case hasAdded:
+		return added
+	default:
+		return removed
+	}
+}
+
+func (r *diffMinimapRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *diffMinimapRenderer) Destroy() {}
@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"regexp"
+
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// searchMatch is a match of the find bar in one line, in grid columns
+type searchMatch struct {
+	line       int
+	start, end int
+}
+
+// NextHunk moves to the next hunk header and reports whether there was one
+func (v *DiffView) NextHunk() bool {
+	return v.moveTo(v.nextLine(1, v.isHunkStart))
+}
+
+// PreviousHunk moves to the previous hunk header
+func (v *DiffView) PreviousHunk() bool {
+	return v.moveTo(v.nextLine(-1, v.isHunkStart))
+}
+
+// NextChange moves to the first line of the next block of added or removed lines
+func (v *DiffView) NextChange() bool {
+	return v.moveTo(v.nextLine(1, v.isChangeStart))
+}
+
+// PreviousChange moves to the first line of the previous block of changes
+func (v *DiffView) PreviousChange() bool {
+	return v.moveTo(v.nextLine(-1, v.isChangeStart))
+}
+
+func (v *DiffView) isHunkStart(line int) bool {
+	return v.kinds[line] == git.DiffLineHunk
+}
+
+func (v *DiffView) isChangeStart(line int) bool {
+	if !isChange(v.kinds[line]) {
+		return false
+	}
+	return line == 0 || !isChange(v.kinds[line-1])
+}
+
+func isChange(kind git.DiffLineKind) bool {
+	return kind == git.DiffLineAdded || kind == git.DiffLineRemoved || kind == git.DiffLineWordChange
+}
+
+// nextLine searches from the cursor in direction for a line matching accept
+// and returns -1 if there is none
+func (v *DiffView) nextLine(direction int, accept func(int) bool) int {
+	start := v.cursor + direction
+	if v.cursor < 0 && direction < 0 {
+		start = len(v.lines) - 1
+	}
+	for line := start; line >= 0 && line < len(v.lines); line += direction {
+		if accept(line) {
+			return line
+		}
+	}
+	return -1
+}
+
+func (v *DiffView) moveTo(line int) bool {
+	if line < 0 {
+		return false
+	}
+	v.ScrollToLine(line)
+	return true
+}
+
+// ScrollToLine scrolls a line of the diff into view and selects it,
+// expanding the collapsed region that hides it if needed
+func (v *DiffView) ScrollToLine(line int) {
+	if line < 0 || line >= len(v.lines) {
+		return
+	}
+
+	if row := v.rows[v.rowOfLine[line]]; row.collapsed() {
+		v.expand(row)
+	}
+
+	v.cursor = line
+	id := v.rowOfLine[line]
+	v.list.Select(id)
+	v.list.ScrollTo(id)
+}
+
+// scrollToRow scrolls to a visible row, used by the minimap
+func (v *DiffView) scrollToRow(id int) {
+	if id < 0 || id >= len(v.rows) {
+		return
+	}
+	if row := v.rows[id]; !row.collapsed() {
+		v.cursor = row.line
+	} else {
+		v.cursor = row.start
+	}
+	v.list.ScrollTo(id)
+}
+
+// Search highlights every match of pattern and returns the number of
+// matches. Unless regex is set the pattern is matched literally
+func (v *DiffView) Search(pattern string, regex, caseSensitive bool) (int, error) {
+	if pattern == "" {
+		v.ClearSearch()
+		return 0, nil
+	}
+
+	if !regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	v.search = re
+	v.findMatches()
+	v.list.Refresh()
+	v.minimap.Refresh()
+	return len(v.matches), nil
+}
+
+// ClearSearch removes all search highlights
+func (v *DiffView) ClearSearch() {
+	v.search = nil
+	v.matches = nil
+	v.match = -1
+	v.list.Refresh()
+	v.minimap.Refresh()
+}
+
+// NextMatch moves to the next search match and returns its index
+func (v *DiffView) NextMatch() int {
+	return v.moveToMatch(1)
+}
+
+// PreviousMatch moves to the previous search match and returns its index
+func (v *DiffView) PreviousMatch() int {
+	return v.moveToMatch(-1)
+}
+
+func (v *DiffView) moveToMatch(direction int) int {
+	if len(v.matches) == 0 {
+		return -1
+	}
+	if v.match < 0 && direction < 0 {
+		v.match = len(v.matches) - 1
+	} else {
+		v.match = (v.match + direction + len(v.matches)) % len(v.matches)
+	}
+	v.ScrollToLine(v.matches[v.match].line)
+	v.list.Refresh()
+	return v.match
+}
+
+func (v *DiffView) findMatches() {
+	v.matches = v.matches[:0]
+	v.match = -1
+	for i, line := range v.lines {
+		for _, loc := range v.search.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			_, start := expandTabs(0, line[:loc[0]], v.tabWidth)
+			_, end := expandTabs(start, line[loc[0]:loc[1]], v.tabWidth)
+			v.matches = append(v.matches, searchMatch{line: i, start: start, end: end})
+		}
+	}
+}
+
+// highlightMatches marks the search matches of line in grid
+func (v *DiffView) highlightMatches(grid *widget.TextGrid, line int) {
+	if len(v.matches) == 0 || len(grid.Rows) == 0 {
+		return
+	}
+
+	palette := v.theme.palette
+	for i, match := range v.matches {
+		if match.line != line {
+			continue
+		}
+		bg := palette.SearchMatch
+		if i == v.match {
+			bg = palette.CurrentMatch
+		}
+		end := min(match.end, len(grid.Rows[0].Cells))
+		if match.start >= end {
+			continue
+		}
+		grid.SetStyleRange(0, match.start, 0, end-1, &widget.CustomTextGridStyle{
+			BGColor: bg,
+			FGColor: palette.SearchText,
+		})
+	}
+}
+
+// MatchCount returns the number of search matches
+func (v *DiffView) MatchCount() int {
+	return len(v.matches)
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+const diffOptionsPreferenceKey = "diffOptions:"
+
+var (
+	whitespaceLabels = []string{"Show whitespace", "Ignore all whitespace", "Ignore whitespace amount", "Ignore whitespace at EOL"}
+	whitespaceModes  = []string{git.WhitespaceShow, git.WhitespaceIgnoreAll, git.WhitespaceIgnoreChange, git.WhitespaceIgnoreAtEOL}
+
+	algorithmLabels = []string{"Default algorithm", "Myers", "Patience", "Histogram"}
+	algorithmModes  = []string{git.AlgorithmDefault, git.AlgorithmMyers, git.AlgorithmPatience, git.AlgorithmHistogram}
+
+	contextLabels = []string{"No context", "1 line", "3 lines", "5 lines", "10 lines", "25 lines", "Full file"}
+	contextValues = []int{-1, 1, 3, 5, 10, 25, git.FullContext}
+
+	renameLabels = []string{"Renames off", "Renames 30%", "Renames 50%", "Renames 70%", "Renames 90%"}
+	renameValues = []int{-1, 30, 50, 70, 90}
+
+	copyLabels = []string{"Copies off", "Copies 30%", "Copies 50%", "Copies 70%", "Copies 90%"}
+	copyValues = []int{0, 30, 50, 70, 90}
+)
+
+// loadDiffOptions restores the diff options saved for the current repository
+func (app *GleamApp) loadDiffOptions() {
+	saved := app.fyneApp.Preferences().String(diffOptionsPreferenceKey + app.git.WorkingDir)
+	if saved == "" {
+		return
+	}
+
+	var opts git.DiffOptions
+	if err := json.Unmarshal([]byte(saved), &opts); err != nil {
+		log.Printf("Error loading diff options: %v", err)
+		return
+	}
+	app.state.diffOptions = opts
+}
+
+func (app *GleamApp) saveDiffOptions() {
+	app.mutex.RLock()
+	data, err := json.Marshal(app.state.diffOptions)
+	app.mutex.RUnlock()
+	if err != nil {
+		log.Printf("Error saving diff options: %v", err)
+		return
+	}
+	app.fyneApp.Preferences().SetString(diffOptionsPreferenceKey+app.git.WorkingDir, string(data))
+}
+
+func (app *GleamApp) setDiffOptions(update func(opts *git.DiffOptions)) {
+	app.mutex.Lock()
+	update(&app.state.diffOptions)
+	app.mutex.Unlock()
+	app.saveDiffOptions()
+	app.refreshDiffView()
+}
+
+// createDiffOptionsBar builds the controls that change how the diff pane
+// computes diffs
+func (app *GleamApp) createDiffOptionsBar() fyne.CanvasObject {
+	opts := app.state.diffOptions
+
+	whitespaceSelect := newOptionSelect(whitespaceLabels, slices.Index(whitespaceModes, opts.Whitespace), func(i int) {
+		app.setDiffOptions(func(opts *git.DiffOptions) { opts.Whitespace = whitespaceModes[i] })
+	})
+
+	contextSelect := newOptionSelect(contextLabels, slices.Index(contextValues, contextLinesOrDefault(opts.ContextLines)), func(i int) {
+		app.setDiffOptions(func(opts *git.DiffOptions) { opts.ContextLines = contextValues[i] })
+	})
+
+	algorithmSelect := newOptionSelect(algorithmLabels, slices.Index(algorithmModes, opts.Algorithm), func(i int) {
+		app.setDiffOptions(func(opts *git.DiffOptions) { opts.Algorithm = algorithmModes[i] })
+	})
+
+	renameSelect := newOptionSelect(renameLabels, slices.Index(renameValues, renameThresholdOrDefault(opts.RenameThreshold)), func(i int) {
+		app.setDiffOptions(func(opts *git.DiffOptions) { opts.RenameThreshold = renameValues[i] })
+	})
+
+	copySelect := newOptionSelect(copyLabels, slices.Index(copyValues, opts.CopyThreshold), func(i int) {
+		app.setDiffOptions(func(opts *git.DiffOptions) { opts.CopyThreshold = copyValues[i] })
+	})
+
+	wordDiffCheck := widget.NewCheck("Word diff", nil)
+	wordDiffCheck.SetChecked(opts.WordDiff)
+	wordDiffCheck.OnChanged = func(checked bool) {
+		app.setDiffOptions(func(opts *git.DiffOptions) { opts.WordDiff = checked })
+	}
+
+	return container.NewHBox(whitespaceSelect, contextSelect, algorithmSelect, renameSelect, copySelect, wordDiffCheck)
+}
+
+// newOptionSelect creates a Select that reports the index of the chosen
+// option, without firing for the initial selection
+func newOptionSelect(labels []string, selected int, onChanged func(int)) *widget.Select {
+	optionSelect := widget.NewSelect(labels, nil)
+	if selected >= 0 {
+		optionSelect.SetSelectedIndex(selected)
+	}
+	optionSelect.OnChanged = func(string) {
+		onChanged(optionSelect.SelectedIndex())
+	}
+	return optionSelect
+}
+
+// contextLinesOrDefault maps git's implicit default to its explicit value
+func contextLinesOrDefault(lines int) int {
+	if lines == 0 {
+		return 3
+	}
+	return lines
+}
+
+// renameThresholdOrDefault maps git's implicit default to its explicit value
+func renameThresholdOrDefault(threshold int) int {
+	if threshold == 0 {
+		return 50
+	}
+	return threshold
+}
@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/alecthomas/chroma/v2"
+
+	"gleam/internal/git"
+)
+
+const (
+	// Diffs above either limit are only rendered once the user asks for them
+	largeDiffBytes = 4 << 20
+	largeDiffLines = 50000
+
+	// Runs of unchanged lines longer than collapseMinLines are collapsed,
+	// keeping collapseContext lines visible on each side
+	collapseContext  = 3
+	collapseMinLines = 12
+
+	// styledRowCacheSize bounds how many styled rows are kept around for
+	// scrolling back
+	styledRowCacheSize = 4096
+)
+
+// diffRow is a visible row of a DiffView. It shows either a single diff line
+// or a collapsed run of unchanged lines
+type diffRow struct {
+	line       int
+	start, end int
+}
+
+func (r diffRow) collapsed() bool {
+	return r.line < 0
+}
+
+// DiffView renders a unified diff in a virtualized list. Lines are only
+// tokenized and styled when they scroll into view, and long runs of
+// unchanged lines are collapsed until expanded
+type DiffView struct {
+	widget.BaseWidget
+
+	lines []string
+	kinds []git.DiffLineKind
+	// wordDiff reads the content as git diff --word-diff=plain, whose
+	// markers are removed from lines and kept as words
+	wordDiff  bool
+	words     map[int][]wordSpan
+	rows      []diffRow
+	rowOfLine []int
+	expanded  map[int]bool
+
+	// cursor is the line last navigated to, or -1
+	cursor  int
+	search  *regexp.Regexp
+	matches []searchMatch
+	match   int
+
+	lexer chroma.Lexer
+	theme *diffTheme
+	// tabWidth is the width the lines were measured with, and
+	// tabWidthSetting returns the one of the repository the diff belongs to
+	tabWidth        int
+	tabWidthSetting func() int
+	cache           map[int]widget.TextGridRow
+
+	list        *widget.List
+	scroll      *container.Scroll
+	minimap     *diffMinimap
+	numberWidth int
+	maxColumns  int
+}
+
+func NewDiffView(content string) *DiffView {
+	return newDiffView(content, false, nil)
+}
+
+// newDiffView creates a DiffView for content, which is a word diff if
+// wordDiff is set. Tabs are expanded to the width tabWidth returns, or to the
+// default width if it is nil
+func newDiffView(content string, wordDiff bool, tabWidth func() int) *DiffView {
+	view := &DiffView{
+		wordDiff:        wordDiff,
+		expanded:        make(map[int]bool),
+		cache:           make(map[int]widget.TextGridRow),
+		theme:           currentDiffTheme(),
+		tabWidthSetting: tabWidth,
+	}
+	view.ExtendBaseWidget(view)
+	view.list = widget.NewList(view.rowCount, view.createRow, view.updateRow)
+	view.scroll = container.NewHScroll(view.list)
+	view.minimap = newDiffMinimap(view)
+	view.SetContent(content)
+	return view
+}
+
+// SetContent replaces the diff shown by the view
+func (v *DiffView) SetContent(content string) {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		v.lines = nil
+	} else {
+		v.lines = strings.Split(content, "\n")
+	}
+	v.kinds = git.ClassifyDiffLines(v.lines, v.wordDiff)
+	v.words = make(map[int][]wordSpan)
+	for i, kind := range v.kinds {
+		if kind == git.DiffLineWordChange {
+			v.lines[i], v.words[i] = parseWordDiff(v.lines[i])
+		}
+	}
+	v.lexer = lexerForDiff(v.lines)
+	v.expanded = make(map[int]bool)
+	v.cache = make(map[int]widget.TextGridRow)
+	v.cursor = -1
+	v.matches = nil
+	v.match = -1
+	v.numberWidth = len(strconv.Itoa(len(v.lines)))
+
+	v.buildRows()
+	v.measure()
+	v.list.UnselectAll()
+	v.list.Refresh()
+	v.list.ScrollToTop()
+	v.minimap.Refresh()
+}
+
+// measure computes the grid columns of the lines, which depend on the tab
+// width, and the columns of the search matches
+func (v *DiffView) measure() {
+	v.tabWidth = v.currentTabWidth()
+	v.maxColumns = 0
+	for _, line := range v.lines {
+		_, columns := expandTabs(0, line, v.tabWidth)
+		v.maxColumns = max(v.maxColumns, columns+1)
+	}
+	if v.search != nil {
+		v.findMatches()
+	}
+}
+
+// Refresh restyles the view if the diff theme or the tab width changed
+// since it was drawn
+func (v *DiffView) Refresh() {
+	theme := currentDiffTheme()
+	if theme != v.theme || v.tabWidth != v.currentTabWidth() {
+		v.theme = theme
+		v.cache = make(map[int]widget.TextGridRow)
+		v.measure()
+		v.minimap.Refresh()
+	}
+	v.BaseWidget.Refresh()
+}
+
+func (v *DiffView) currentTabWidth() int {
+	if v.tabWidthSetting == nil {
+		return defaultTabWidth
+	}
+	return v.tabWidthSetting()
+}
+
+func (v *DiffView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, nil, nil, v.minimap, v.scroll))
+}
+
+// buildRows computes the visible rows, collapsing long unchanged runs that
+// have not been expanded
+func (v *DiffView) buildRows() {
+	v.rows = v.rows[:0]
+	v.rowOfLine = make([]int, len(v.lines))
+	for i := 0; i < len(v.lines); {
+		if v.kinds[i] != git.DiffLineContext {
+			v.rowOfLine[i] = len(v.rows)
+			v.rows = append(v.rows, diffRow{line: i})
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(v.lines) && v.kinds[end] == git.DiffLineContext {
+			end++
+		}
+
+		hiddenStart, hiddenEnd := i+collapseContext, end-collapseContext
+		if end-i < collapseMinLines || v.expanded[hiddenStart] {
+			hiddenStart, hiddenEnd = end, end
+		}
+		for line := i; line < end; line++ {
+			if line == hiddenStart {
+				for hidden := hiddenStart; hidden < hiddenEnd; hidden++ {
+					v.rowOfLine[hidden] = len(v.rows)
+				}
+				v.rows = append(v.rows, diffRow{line: -1, start: hiddenStart, end: hiddenEnd})
+				line = hiddenEnd - 1
+				continue
+			}
+			v.rowOfLine[line] = len(v.rows)
+			v.rows = append(v.rows, diffRow{line: line})
+		}
+		i = end
+	}
+}
+
+func (v *DiffView) expand(row diffRow) {
+	v.expanded[row.start] = true
+	v.buildRows()
+	v.list.Refresh()
+	v.minimap.Refresh()
+}
+
+func (v *DiffView) rowCount() int {
+	return len(v.rows)
+}
+
+func (v *DiffView) createRow() fyne.CanvasObject {
+	numbers := widget.NewTextGrid()
+	line := NewTappableTextGrid()
+
+	// The spacer makes every row as wide as the longest line, so the
+	// surrounding scroll container can scroll horizontally
+	spacer := canvas.NewRectangle(color.Transparent)
+	spacer.SetMinSize(v.rowMinSize())
+
+	return container.NewStack(spacer, container.NewBorder(nil, nil, numbers, nil, line))
+}
+
+func (v *DiffView) rowMinSize() fyne.Size {
+	cell := fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true})
+	return fyne.NewSize(float32(v.maxColumns+v.numberWidth+1)*cell.Width, 0)
+}
+
+func (v *DiffView) updateRow(id widget.ListItemID, item fyne.CanvasObject) {
+	if id >= len(v.rows) {
+		return
+	}
+	row := v.rows[id]
+
+	stack := item.(*fyne.Container)
+	stack.Objects[0].(*canvas.Rectangle).SetMinSize(v.rowMinSize())
+	border := stack.Objects[1].(*fyne.Container)
+	line := border.Objects[0].(*TappableTextGrid)
+	numbers := border.Objects[1].(*widget.TextGrid)
+
+	if row.collapsed() {
+		numbers.SetText(strings.Repeat(" ", v.numberWidth))
+		line.SetText(fmt.Sprintf("  ⋯ %d unchanged lines, click to expand", row.end-row.start))
+		line.SetRowStyle(0, &widget.CustomTextGridStyle{BGColor: v.theme.palette.Collapsed, FGColor: theme.DisabledColor()})
+		line.OnTappedRow = func(int, *fyne.PointEvent) { v.expand(row) }
+		return
+	}
+
+	numbers.SetText(fmt.Sprintf("%*d", v.numberWidth, row.line+1))
+	numbers.SetRowStyle(0, &widget.CustomTextGridStyle{FGColor: theme.DisabledColor()})
+	line.OnTappedRow = nil
+
+	if styled, ok := v.cache[row.line]; ok {
+		line.Rows = []widget.TextGridRow{{Cells: slices.Clone(styled.Cells), Style: styled.Style}}
+	} else {
+		line.TabWidth = v.tabWidth
+		line.SetText(v.lines[row.line])
+		highlightDiffLine(&line.TextGrid, 0, v.lines[row.line], v.kinds[row.line], v.lexer, v.theme, v.tabWidth)
+		highlightWordSpans(&line.TextGrid, 0, v.lines[row.line], v.words[row.line], v.theme, v.tabWidth)
+		if len(v.cache) >= styledRowCacheSize {
+			v.cache = make(map[int]widget.TextGridRow)
+		}
+		v.cache[row.line] = widget.TextGridRow{Cells: slices.Clone(line.Rows[0].Cells), Style: line.Rows[0].Style}
+	}
+
+	v.highlightMatches(&line.TextGrid, row.line)
+	line.Refresh()
+}
+
+// newLazyDiffView returns a DiffView for content, which is a word diff if
+// wordDiff is set. Huge diffs get a placeholder instead that builds the view
+// when requested, and onLoad is called with the view once it exists
+func newLazyDiffView(content string, wordDiff bool, tabWidth func() int, onLoad func(*DiffView)) fyne.CanvasObject {
+	lineCount := strings.Count(content, "\n")
+	if len(content) < largeDiffBytes && lineCount < largeDiffLines {
+		view := newDiffView(content, wordDiff, tabWidth)
+		if onLoad != nil {
+			onLoad(view)
+		}
+		return view
+	}
+
+	message := fmt.Sprintf("This diff is large (%d lines, %s) and is not shown automatically.",
+		lineCount, formatFileSize(int64(len(content))))
+	return largeDiffPlaceholder(message, func(show func(fyne.CanvasObject)) {
+		view := newDiffView(content, wordDiff, tabWidth)
+		show(view)
+		if onLoad != nil {
+			onLoad(view)
+		}
+	})
+}
+
+// largeDiffPlaceholder stands in for a diff that is too large to show right
+// away. Its button calls load, which passes the diff view to show once it is
+// ready
+func largeDiffPlaceholder(message string, load func(show func(fyne.CanvasObject))) fyne.CanvasObject {
+	holder := container.NewStack()
+	loadButton := widget.NewButton("Load diff", nil)
+	loadButton.OnTapped = func() {
+		loadButton.Disable()
+		load(func(view fyne.CanvasObject) {
+			holder.Objects = []fyne.CanvasObject{view}
+			holder.Refresh()
+		})
+	}
+	holder.Objects = []fyne.CanvasObject{container.NewCenter(container.NewVBox(widget.NewLabel(message), loadButton))}
+	return holder
+}
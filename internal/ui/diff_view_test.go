@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// generatedDiff returns a diff of a Go file with lines lines, in hunks that
+// alternate between unchanged, removed and added lines
+func generatedDiff(lines int) string {
+	var b strings.Builder
+	b.WriteString("diff --git a/generated.go b/generated.go\n--- a/generated.go\n+++ b/generated.go\n")
+	for i := range lines {
+		switch {
+		case i%40 == 0:
+			fmt.Fprintf(&b, "@@ -%d,40 +%d,40 @@ func generated() {\n", i+1, i+1)
+		case i%40 < 30:
+			fmt.Fprintf(&b, " \tvalue%d := compute(%d, \"unchanged\")\n", i, i)
+		case i%2 == 0:
+			fmt.Fprintf(&b, "-\tvalue%d := compute(%d, \"old\")\n", i, i)
+		default:
+			fmt.Fprintf(&b, "+\tvalue%d := compute(%d, \"new\")\n", i, i)
+		}
+	}
+	return b.String()
+}
+
+func TestLazyDiffViewDefersLargeDiffs(t *testing.T) {
+	test.NewTempApp(t)
+
+	loaded := 0
+	if _, ok := newLazyDiffView(generatedDiff(100), false, nil, func(*DiffView) { loaded++ }).(*DiffView); !ok || loaded != 1 {
+		t.Fatalf("small diff is not shown right away")
+	}
+
+	placeholder := newLazyDiffView(generatedDiff(largeDiffLines+1), false, nil, func(*DiffView) { loaded++ })
+	if _, ok := placeholder.(*DiffView); ok || loaded != 1 {
+		t.Fatalf("large diff is shown before it is requested")
+	}
+	test.Tap(findButton(t, placeholder, "Load diff"))
+	if loaded != 2 {
+		t.Errorf("large diff is not loaded when requested")
+	}
+}
+
+func BenchmarkClassifyDiffLines(b *testing.B) {
+	lines := strings.Split(generatedDiff(100000), "\n")
+	b.ResetTimer()
+	for range b.N {
+		git.ClassifyDiffLines(lines, false)
+	}
+}
+
+// BenchmarkDiffViewSetContent measures loading a 100k-line diff, which must
+// not depend on styling lines that are not visible
+func BenchmarkDiffViewSetContent(b *testing.B) {
+	test.NewTempApp(b)
+	content := generatedDiff(100000)
+	view := NewDiffView("")
+	b.ResetTimer()
+	for range b.N {
+		view.SetContent(content)
+	}
+}
+
+// BenchmarkDiffViewScroll measures styling one screen of rows of a 100k-line
+// diff, as scrolling to a part that was not shown before does
+func BenchmarkDiffViewScroll(b *testing.B) {
+	test.NewTempApp(b)
+	view := NewDiffView(generatedDiff(100000))
+	row := view.createRow()
+	const screen = 50
+	b.ResetTimer()
+	for i := range b.N {
+		view.cache = make(map[int]widget.TextGridRow)
+		start := i * screen % len(view.rows)
+		for id := start; id < min(start+screen, len(view.rows)); id++ {
+			view.updateRow(id, row)
+		}
+	}
+}
+
+// findButton returns the button labeled text below object
+func findButton(t *testing.T, object fyne.CanvasObject, text string) *widget.Button {
+	t.Helper()
+	var found *widget.Button
+	var walk func(fyne.CanvasObject)
+	walk = func(object fyne.CanvasObject) {
+		switch object := object.(type) {
+		case *widget.Button:
+			if object.Text == text {
+				found = object
+			}
+		case *fyne.Container:
+			for _, child := range object.Objects {
+				walk(child)
+			}
+		}
+	}
+	walk(object)
+	if found == nil {
+		t.Fatalf("no %q button", text)
+	}
+	return found
+}
+
+func TestDiffViewUsesTabWidthOfItsRepository(t *testing.T) {
+	app := newTestGleamApp(t, newChangedBackend(t))
+	app.state.settings.TabWidth = 3
+	other := func() int { return 8 }
+
+	diff := "@@ -1 +1 @@\n+\t\t\t\tx"
+	view, otherView := newDiffView(diff, false, app.tabWidth), newDiffView(diff, false, other)
+	if view.maxColumns != 14 || otherView.maxColumns != 34 {
+		t.Errorf("the line with tabs is measured %d and %d columns wide, want 14 and 34", view.maxColumns, otherView.maxColumns)
+	}
+
+	app.state.settings.TabWidth = 4
+	view.Refresh()
+	otherView.Refresh()
+	if view.maxColumns != 18 || otherView.maxColumns != 34 {
+		t.Errorf("after changing the tab width of the repository the line is %d and %d columns wide, want 18 and 34", view.maxColumns, otherView.maxColumns)
+	}
+}
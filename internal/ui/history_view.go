@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+	"gleam/internal/task"
+)
+
+// showFileHistory opens a window listing every commit that touched file,
+// with the diff of the selected commit and a comparison of any two revisions
+func (app *GleamApp) showFileHistory(file string) {
+	var entries []git.LogEntry
+	app.tasks.Go(func(ctx context.Context) error {
+		defer app.logTiming("File history")()
+		var err error
+		entries, err = app.git.WithContext(ctx).FileHistory(file)
+		return err
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+			return
+		}
+		app.openHistoryWindow(file, entries)
+	})
+}
+
+func (app *GleamApp) openHistoryWindow(file string, entries []git.LogEntry) {
+	window := app.fyneApp.NewWindow("History of " + file)
+	diffContainer := container.NewStack(NewDiffView(""))
+	// showDiff loads a diff with load, replacing a load still in progress
+	diffTask := windowTask(window, "history-diff")
+	showDiff := func(load func(command *git.GitCommand) (string, error)) {
+		task.Latest(app.tasks, diffTask, func(ctx context.Context) (string, error) {
+			return load(app.git.WithContext(ctx))
+		}, func(diff string, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			diffContainer.Objects[0] = newLazyDiffView(diff, false, app.tabWidth, nil)
+			diffContainer.Refresh()
+		})
+	}
+
+	commitList := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := entries[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(entry.Subject)
+			row.Objects[1].(*widget.Label).SetText(historyEntryLabel(entry))
+		},
+	)
+	selected := -1
+	commitList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		entry := entries[id]
+		showDiff(func(command *git.GitCommand) (string, error) {
+			return command.FileDiffAtCommit(entry.Hash, entry.Path)
+		})
+	}
+	commitList.OnUnselected = func(widget.ListItemID) { selected = -1 }
+
+	options := make([]string, len(entries))
+	for i, entry := range entries {
+		options[i] = historyEntryLabel(entry)
+	}
+	fromSelect := widget.NewSelect(options, nil)
+	fromSelect.PlaceHolder = "From revision"
+	toSelect := widget.NewSelect(options, nil)
+	toSelect.PlaceHolder = "To revision"
+	compareButton := widget.NewButton("Compare", func() {
+		from, to := fromSelect.SelectedIndex(), toSelect.SelectedIndex()
+		if from < 0 || to < 0 {
+			return
+		}
+		commitList.UnselectAll()
+		fromEntry, toEntry := entries[from], entries[to]
+		showDiff(func(command *git.GitCommand) (string, error) {
+			return command.CompareFileRevisions(fromEntry.Hash, fromEntry.Path, toEntry.Hash, toEntry.Path)
+		})
+	})
+
+	resetButton := widget.NewButton("Reset branch to commit…", func() {
+		if selected >= 0 {
+			app.showResetDialog(window, entries[selected].Hash, nil)
+		}
+	})
+	restoreButton := widget.NewButton("Restore file from commit…", func() {
+		if selected >= 0 {
+			app.showRestoreFileDialog(window, entries[selected].Hash, entries[selected].Path, file)
+		}
+	})
+
+	compareBar := container.NewHBox(fromSelect, toSelect, compareButton)
+	actionBar := container.NewHBox(resetButton, restoreButton)
+	split := container.NewHSplit(commitList, diffContainer)
+	split.Offset = 0.3
+
+	window.SetContent(container.NewBorder(compareBar, actionBar, nil, nil, split))
+	window.Resize(fyne.NewSize(1100, 650))
+	window.Show()
+
+	if len(entries) > 0 {
+		commitList.Select(0)
+	}
+}
+
+func historyEntryLabel(entry git.LogEntry) string {
+	return fmt.Sprintf("%s  %s  %s", shortHash(entry.Hash), entry.Author, entry.Date.Format("2006-01-02 15:04"))
+}
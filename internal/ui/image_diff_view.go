@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	_ "golang.org/x/image/webp"
+
+	"gleam/internal/git"
+)
+
+const (
+	imageModeSideBySide = "Side by side"
+	imageModeSwipe      = "Swipe"
+	imageModeOnionSkin  = "Onion skin"
+	imageModeDifference = "Difference"
+
+	maxSVGSize = 4096
+)
+
+var imageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg"}
+
+// changedPixelColor marks pixels that differ in the difference mode
+var changedPixelColor = color.NRGBA{R: 255, G: 0, B: 170, A: 255}
+
+func isImageFile(path string) bool {
+	return slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// loadImageDiff decodes both versions of file and returns a builder for the
+// image comparison. It reports false if neither version can be decoded so
+// the caller can fall back to a text diff
+func (app *GleamApp) loadImageDiff(backend git.Backend, file string) (func() fyne.CanvasObject, bool) {
+	defer app.logTiming("Image diff")()
+
+	oldData, newData, err := backend.FileVersions(file)
+	if err != nil {
+		log.Printf("Error reading image versions: %v", err)
+		return nil, false
+	}
+	// Images stored in LFS are compared by their content if it was downloaded
+	oldData, newData = backend.ResolveLFS(oldData), backend.ResolveLFS(newData)
+
+	oldImage, err := decodeImage(file, oldData)
+	if err != nil {
+		log.Printf("Error decoding old image: %v", err)
+	}
+	newImage, err := decodeImage(file, newData)
+	if err != nil {
+		log.Printf("Error decoding new image: %v", err)
+	}
+	if oldImage == nil && newImage == nil {
+		return nil, false
+	}
+
+	return func() fyne.CanvasObject {
+		return createImageDiffView(oldData, newData, oldImage, newImage)
+	}, true
+}
+
+// decodeImage decodes a raster image or rasterizes an SVG document. It
+// returns nil for missing content
+func decodeImage(path string, data []byte) (image.Image, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		icon, err := oksvg.ReadIconStream(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		width := min(max(int(icon.ViewBox.W), 1), maxSVGSize)
+		height := min(max(int(icon.ViewBox.H), 1), maxSVGSize)
+		icon.SetTarget(0, 0, float64(width), float64(height))
+
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+		icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+		return img, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// createImageDiffView shows the old and new versions of an image in one of
+// several comparison modes
+func createImageDiffView(oldData, newData []byte, oldImage, newImage image.Image) fyne.CanvasObject {
+	content := container.NewStack()
+	info := widget.NewLabel(fmt.Sprintf("Old: %s    New: %s",
+		imageInfo(oldImage, oldData), imageInfo(newImage, newData)))
+
+	slider := widget.NewSlider(0, 1)
+	slider.Step = 0.01
+	slider.SetValue(0.5)
+	slider.Hide()
+
+	modes := []string{imageModeSideBySide}
+	if oldImage != nil && newImage != nil {
+		modes = append(modes, imageModeSwipe, imageModeOnionSkin, imageModeDifference)
+	}
+
+	showMode := func(mode string) {
+		slider.OnChanged = nil
+		slider.Hide()
+
+		switch mode {
+		case imageModeSwipe:
+			swipe := newSwipeView(oldImage, newImage, slider.Value)
+			slider.OnChanged = swipe.setPosition
+			slider.Show()
+			content.Objects = []fyne.CanvasObject{swipe.container}
+		case imageModeOnionSkin:
+			oldCanvas := newImageCanvas(oldImage)
+			newCanvas := newImageCanvas(newImage)
+			newCanvas.Translucency = 1 - slider.Value
+			slider.OnChanged = func(value float64) {
+				newCanvas.Translucency = 1 - value
+				newCanvas.Refresh()
+			}
+			slider.Show()
+			content.Objects = []fyne.CanvasObject{oldCanvas, newCanvas}
+		case imageModeDifference:
+			diff, changed := differenceImage(oldImage, newImage)
+			bounds := diff.Bounds()
+			label := widget.NewLabel(fmt.Sprintf("%d of %d pixels changed", changed, bounds.Dx()*bounds.Dy()))
+			content.Objects = []fyne.CanvasObject{container.NewBorder(nil, label, nil, nil, newImageCanvas(diff))}
+		default:
+			content.Objects = []fyne.CanvasObject{container.NewGridWithColumns(2,
+				labeledImage("Old", oldImage),
+				labeledImage("New", newImage),
+			)}
+		}
+		content.Refresh()
+	}
+
+	modeSelect := widget.NewRadioGroup(modes, showMode)
+	modeSelect.Horizontal = true
+	modeSelect.Required = true
+	modeSelect.SetSelected(imageModeSideBySide)
+
+	header := container.NewVBox(container.NewHBox(modeSelect), info, slider)
+	return container.NewBorder(header, nil, nil, nil, content)
+}
+
+func newImageCanvas(img image.Image) *canvas.Image {
+	imageCanvas := canvas.NewImageFromImage(img)
+	imageCanvas.FillMode = canvas.ImageFillContain
+	imageCanvas.ScaleMode = canvas.ImageScalePixels
+	return imageCanvas
+}
+
+func labeledImage(title string, img image.Image) fyne.CanvasObject {
+	label := widget.NewLabelWithStyle(title, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	if img == nil {
+		return container.NewBorder(label, nil, nil, nil, widget.NewLabelWithStyle("(none)", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}))
+	}
+	return container.NewBorder(label, nil, nil, nil, newImageCanvas(img))
+}
+
+func imageInfo(img image.Image, data []byte) string {
+	if img == nil {
+		return "(none)"
+	}
+	bounds := img.Bounds()
+	return fmt.Sprintf("%d × %d, %s", bounds.Dx(), bounds.Dy(), formatFileSize(int64(len(data))))
+}
+
+// comparisonBounds is the area covering both images, anchored at the origin
+func comparisonBounds(oldImage, newImage image.Image) image.Rectangle {
+	oldBounds, newBounds := oldImage.Bounds(), newImage.Bounds()
+	return image.Rect(0, 0, max(oldBounds.Dx(), newBounds.Dx()), max(oldBounds.Dy(), newBounds.Dy()))
+}
+
+// swipeView shows the old image left of a divider and the new image right
+// of it. The new image is clipped by a scroll container, so moving the
+// divider only moves objects and never draws a new image
+type swipeView struct {
+	container *fyne.Container
+	newCanvas *canvas.Image
+	divider   *canvas.Rectangle
+	// bounds is the area covering both images, in pixels
+	bounds image.Rectangle
+	// position is where the divider is, as a fraction of the image width
+	position float64
+}
+
+func newSwipeView(oldImage, newImage image.Image, position float64) *swipeView {
+	bounds := comparisonBounds(oldImage, newImage)
+	v := &swipeView{
+		newCanvas: newImageCanvas(padImage(newImage, bounds)),
+		divider:   canvas.NewRectangle(changedPixelColor),
+		bounds:    bounds,
+		position:  position,
+	}
+	clip := container.NewScroll(container.NewWithoutLayout(v.newCanvas))
+	clip.Direction = container.ScrollNone
+	v.container = container.New(v, newImageCanvas(padImage(oldImage, bounds)), clip, v.divider)
+	return v
+}
+
+func (v *swipeView) setPosition(position float64) {
+	v.position = position
+	v.Layout(v.container.Objects, v.container.Size())
+	v.divider.Refresh()
+}
+
+// Layout places the divider at position within the area the images are
+// scaled into, and the new image right of it at the same place as the old
+func (v *swipeView) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	oldCanvas, clip := objects[0], objects[1]
+	oldCanvas.Move(fyne.NewPos(0, 0))
+	oldCanvas.Resize(size)
+
+	width, height := float32(v.bounds.Dx()), float32(v.bounds.Dy())
+	scale := min(size.Width/width, size.Height/height)
+	left, top := (size.Width-width*scale)/2, (size.Height-height*scale)/2
+	split := left + width*scale*float32(v.position)
+
+	clip.Move(fyne.NewPos(split, 0))
+	clip.Resize(fyne.NewSize(size.Width-split, size.Height))
+	v.newCanvas.Move(fyne.NewPos(-split, 0))
+	v.newCanvas.Resize(size)
+	v.divider.Move(fyne.NewPos(split, top))
+	v.divider.Resize(fyne.NewSize(1, height*scale))
+}
+
+func (v *swipeView) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return objects[0].MinSize()
+}
+
+// padImage returns img drawn at the origin of bounds, or img itself if it
+// already covers exactly that area
+func padImage(img image.Image, bounds image.Rectangle) image.Image {
+	if img.Bounds() == bounds {
+		return img
+	}
+	result := image.NewNRGBA(bounds)
+	draw.Draw(result, img.Bounds().Sub(img.Bounds().Min), img, img.Bounds().Min, draw.Src)
+	return result
+}
+
+// differenceImage dims the new image and marks every pixel that differs from
+// the old image. It also returns the number of changed pixels
+func differenceImage(oldImage, newImage image.Image) (image.Image, int) {
+	bounds := comparisonBounds(oldImage, newImage)
+	result := image.NewNRGBA(bounds)
+	oldMin, newMin := oldImage.Bounds().Min, newImage.Bounds().Min
+	changed := 0
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			oldPixel := pixelAt(oldImage, oldMin.X+x, oldMin.Y+y)
+			newPixel := pixelAt(newImage, newMin.X+x, newMin.Y+y)
+			if oldPixel != newPixel {
+				result.SetNRGBA(x, y, changedPixelColor)
+				changed++
+				continue
+			}
+
+			gray := uint8((uint16(newPixel.R) + uint16(newPixel.G) + uint16(newPixel.B)) / 3)
+			result.SetNRGBA(x, y, color.NRGBA{R: gray, G: gray, B: gray, A: newPixel.A / 3})
+		}
+	}
+	return result, changed
+}
+
+func pixelAt(img image.Image, x, y int) color.NRGBA {
+	if !image.Pt(x, y).In(img.Bounds()) {
+		return color.NRGBA{}
+	}
+	return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+}
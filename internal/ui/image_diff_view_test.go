@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+func solidImage(width, height int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestSwipeViewClipsNewImage(t *testing.T) {
+	test.NewTempApp(t)
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	swipe := newSwipeView(solidImage(100, 50, red), solidImage(100, 50, blue), 0.5)
+	shown := swipe.newCanvas.Image
+
+	window := test.NewWindow(swipe.container)
+	defer window.Close()
+	window.SetPadded(false)
+	window.Resize(fyne.NewSize(200, 100))
+
+	colorAt := func(x, y int) color.NRGBA {
+		return color.NRGBAModel.Convert(window.Canvas().Capture().At(x, y)).(color.NRGBA)
+	}
+	if got := colorAt(50, 50); got != red {
+		t.Errorf("left of the divider is %v, want the old image", got)
+	}
+	if got := colorAt(150, 50); got != blue {
+		t.Errorf("right of the divider is %v, want the new image", got)
+	}
+
+	swipe.setPosition(0.25)
+	if got := colorAt(75, 50); got != blue {
+		t.Errorf("after moving the divider left, %v is shown right of it", got)
+	}
+	if swipe.newCanvas.Image != shown {
+		t.Errorf("moving the divider replaced the image")
+	}
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"context"
+	"path"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// isLFSFile reports whether file is one of the changed files stored in LFS
+func (app *GleamApp) isLFSFile(file string) bool {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	return app.state.lfsFiles[file]
+}
+
+// lfsTrackedFiles returns which of files are stored in LFS. Pointer files
+// it misses are still recognized by their content when they are diffed
+func (app *GleamApp) lfsTrackedFiles(files []string) map[string]bool {
+	tracked, err := app.backend.LFSTracked(files)
+	if err != nil {
+		return nil
+	}
+	return tracked
+}
+
+// lfsDiffView summarizes the pointers of both versions of an LFS file in
+// place of the pointer text
+func lfsDiffView(change git.LFSChange) fyne.CanvasObject {
+	side := func(label string, pointer *git.LFSPointer, local bool) fyne.CanvasObject {
+		if pointer == nil {
+			return widget.NewLabel(label + ": (none)")
+		}
+		stored := "stored locally"
+		if !local {
+			stored = "not downloaded"
+		}
+		return widget.NewLabel(label + ": " + formatFileSize(pointer.Size) + ", " + stored + "\n    sha256 " + pointer.OID)
+	}
+
+	title := "Git LFS file changed"
+	if change.Old != nil && change.New != nil && *change.Old == *change.New {
+		title = "Git LFS file unchanged"
+	}
+	return container.NewVBox(
+		widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(change.Path),
+		side("Old", change.Old, change.OldLocal),
+		side("New", change.New, change.NewLocal),
+	)
+}
+
+// lfsTrackPattern suggests the pattern to track file with: its extension,
+// or the file itself if it has none
+func lfsTrackPattern(file string) string {
+	if ext := path.Ext(file); ext != "" {
+		return "*" + ext
+	}
+	return file
+}
+
+// showTrackLFS asks for a pattern, suggested from file, and stores the files
+// matching it in LFS
+func (app *GleamApp) showTrackLFS(file string) {
+	pattern := widget.NewEntry()
+	pattern.SetText(lfsTrackPattern(file))
+	hint := widget.NewLabel("New and changed files matching the pattern are stored in Git LFS. Files committed before keep their content in git.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Pattern", pattern),
+		widget.NewFormItem("", hint),
+	}
+	form := dialog.NewForm("Track with Git LFS", "Track", "Cancel", items, func(confirmed bool) {
+		if confirmed && pattern.Text != "" {
+			app.changeLFSPatterns(func() error { return app.git.TrackLFS(pattern.Text) })
+		}
+	}, app.ui.window)
+	form.Resize(fyne.NewSize(480, 220))
+	form.Show()
+}
+
+// showUntrackLFS asks which LFS pattern to remove, preselecting one that
+// matches file
+func (app *GleamApp) showUntrackLFS(file string) {
+	patterns, err := app.git.LFSPatterns()
+	if err != nil {
+		dialog.ShowError(err, app.ui.window)
+		return
+	}
+	if len(patterns) == 0 {
+		dialog.ShowInformation("Untrack from Git LFS", "No .gitattributes file has Git LFS patterns.", app.ui.window)
+		return
+	}
+
+	sel := widget.NewSelect(patterns, nil)
+	if index := slices.IndexFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, path.Base(file))
+		exact, _ := path.Match(pattern, file)
+		return matched || exact
+	}); index >= 0 {
+		sel.SetSelectedIndex(index)
+	}
+
+	items := []*widget.FormItem{widget.NewFormItem("Pattern", sel)}
+	dialog.ShowForm("Untrack from Git LFS", "Untrack", "Cancel", items, func(confirmed bool) {
+		if confirmed && sel.Selected != "" {
+			app.changeLFSPatterns(func() error { return app.git.UntrackLFS(sel.Selected) })
+		}
+	}, app.ui.window)
+}
+
+func (app *GleamApp) changeLFSPatterns(change func() error) {
+	app.tasks.Serial(func(context.Context) error { return change() }, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+		}
+		app.refreshFileList()
+	})
+}
@@ -1,14 +1,16 @@
 package ui
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
@@ -16,7 +18,10 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"gleam/internal/askpass"
 	"gleam/internal/git"
+	"gleam/internal/task"
+	"gleam/internal/themes"
 )
 
 type FileState struct {
@@ -32,24 +37,98 @@ type Commit struct {
 
 type GleamApp struct {
 	ui struct {
-		description   *widget.Entry
-		summary       *widget.Entry
+		description   *shortcutEntry
+		summary       *shortcutEntry
 		actionBar     *fyne.Container
-		diffViewer    *widget.TextGrid
+		diffViewer    *DiffView
 		fileList      *widget.List
 		window        fyne.Window
 		diffContainer *fyne.Container
 		popup         *widget.PopUpMenu
 		toolbar       *fyne.Container
+		viewMode      *widget.RadioGroup
+		find          struct {
+			bar       *fyne.Container
+			entry     *findEntry
+			regex     *widget.Check
+			matchCase *widget.Check
+			count     *widget.Label
+		}
+		console struct {
+			panel      fyne.CanvasObject
+			main       fyne.CanvasObject
+			dock       *fyne.Container
+			dockButton *widget.Button
+			window     fyne.Window
+			docked     bool
+		}
 	}
 	state struct {
 		commit         Commit
 		files          FileState
 		activeFileDiff string
 		activeDiff     string
+		diffOptions    git.DiffOptions
+		settings       Settings
+		submodules     []git.Submodule
+		lfsFiles       map[string]bool
+		viewMode       string
+		blame          struct {
+			path     string
+			revision string
+		}
+	}
+	credentials struct {
+		sync.Mutex
+		cache map[string]string
+		// used holds the prompts answered from cache since the last
+		// authentication failure
+		used map[string]bool
+	}
+	undo struct {
+		sync.Mutex
+		actions []undoAction
+		next    uint64
 	}
-	git   *git.GitCommand
-	mutex sync.RWMutex
+	// openRepository opens another repository, such as a submodule, in a
+	// tab of the workspace. It is nil outside of a workspace
+	openRepository  func(path string)
+	fyneApp         fyne.App
+	operations      operationLog
+	themes          []themes.Theme
+	appliedSettings *Settings
+	autoFetch       struct {
+		stop    chan struct{}
+		minutes int
+	}
+	keymap  *keymap
+	git     *git.GitCommand
+	backend git.Backend
+	askpass *askpass.Server
+	tasks   *task.Scheduler
+	// updates are dropped once the repository is closed
+	updates *uiUpdates
+	mutex   sync.RWMutex
+}
+
+// openBackend selects the git backend named in the settings, falling back
+// to running git if it cannot be opened
+func (app *GleamApp) openBackend(name string) {
+	backend, err := git.OpenBackend(name, app.git)
+	if err != nil {
+		log.Printf("Error opening %s backend, running git instead: %v", name, err)
+		backend = app.git
+	}
+	app.backend = backend
+}
+
+// diffPaneTask is the scheduler key of loads for the diff pane
+const diffPaneTask = "diff-pane"
+
+// windowTask returns the scheduler key of loads named name for window, so
+// that two windows of the same kind do not cancel each other's loads
+func windowTask(window fyne.Window, name string) string {
+	return fmt.Sprintf("%s-%p", name, window)
 }
 
 func (app *GleamApp) logTiming(operation string) func() {
@@ -60,80 +139,87 @@ func (app *GleamApp) logTiming(operation string) func() {
 	}
 }
 
-func NewGleamApp() *GleamApp {
-	defer log.Printf("Creating new Gleam app...")
-
-	application := app.NewWithID("com.bennowo.gleam")
-	gleamApp := &GleamApp{
-		state: struct {
-			commit         Commit
-			files          FileState
-			activeFileDiff string
-			activeDiff     string
-		}{
-			commit: Commit{},
-			files: FileState{
-				staged:   make([]string, 0),
-				unstaged: make([]string, 0),
-				ignored:  make([]string, 0),
-			},
-		},
-	}
+// runOnUI queues fn to run on the goroutine that applies results of
+// background tasks, so those updates never race with each other
+func (app *GleamApp) runOnUI(fn func()) {
+	app.updates.run(fn)
+}
 
-	logLifecycle(application, gleamApp)
-	window := application.NewWindow("Gleam")
-	application.SetIcon(theme.FileIcon())
+// NewGleamApp opens the repository at workingDir. Its content is shown in
+// window, which it shares with the other open repositories, and keys typed
+// into its entries are looked up in keymap
+func NewGleamApp(window fyne.Window, keymap *keymap, workingDir string) *GleamApp {
+	defer log.Printf("Creating new Gleam app for %s...", workingDir)
 
-	workingDir, err := filepath.Abs("/Users/benno/coding/gleam")
-	if err != nil {
-		log.Fatalf("Error getting absolute path: %v", err)
+	gleamApp := newGleamApp(fyne.CurrentApp(), window, keymap, git.NewGitCommand(workingDir), nil)
+	gleamApp.startAskpass()
+	gleamApp.scheduleAutoFetch(gleamApp.state.settings.AutoFetchMinutes)
+	return gleamApp
+}
+
+// newGleamApp builds a GleamApp on fyneApp for the repository of command. A
+// nil backend is chosen from the settings. The file list, the diff pane,
+// staging, committing and undo only use the backend, so with the app of
+// Fyne's test package and a git.FakeBackend they run without a display or a
+// repository. Credential prompts and auto-fetch are only started by
+// NewGleamApp, and the dialogs for remotes, history and the like run git
+func newGleamApp(fyneApp fyne.App, window fyne.Window, keymap *keymap, command *git.GitCommand, backend git.Backend) *GleamApp {
+	gleamApp := &GleamApp{fyneApp: fyneApp}
+	gleamApp.state.files = FileState{
+		staged:   make([]string, 0),
+		unstaged: make([]string, 0),
+		ignored:  make([]string, 0),
 	}
-	gleamApp.git = git.NewGitCommand(workingDir)
+	gleamApp.updates = newUIUpdates()
+	gleamApp.tasks = task.NewScheduler(gleamApp.runOnUI)
+
+	gleamApp.git = command
+	gleamApp.git.Observer = gleamApp.operations.record
 	gleamApp.ui.window = window
+	gleamApp.keymap = keymap
+	gleamApp.loadSettings()
+	gleamApp.git.Binary = gleamApp.state.settings.GitPath
+	if backend != nil {
+		gleamApp.backend = backend
+	} else {
+		gleamApp.openBackend(gleamApp.state.settings.Backend)
+	}
+	gleamApp.state.viewMode = gleamApp.state.settings.DefaultViewMode
+	gleamApp.loadDiffOptions()
+	gleamApp.credentials.cache = make(map[string]string)
+	gleamApp.credentials.used = make(map[string]bool)
+	gleamApp.loadThemes()
 
 	fetchButton := widget.NewButton("Fetch", func() {
-		progress := dialog.NewProgress("Fetching", "Fetching changes from remote...", window)
-		progress.Show()
-		go func() {
-			err := gleamApp.git.Fetch()
-			progress.Hide()
-			if err != nil {
-				dialog.ShowError(err, window)
-			}
-		}()
+		gleamApp.fetch(git.FetchOptions{})
 	})
 	fetchButton.Icon = theme.DownloadIcon()
 
-	pullButton := widget.NewButton("Pull", func() {
-		progress := dialog.NewProgress("Pulling", "Pulling changes from remote...", window)
-		progress.Show()
-		go func() {
-			err := gleamApp.git.Pull()
-			progress.Hide()
-			if err != nil {
-				dialog.ShowError(err, window)
-			} else {
-				gleamApp.refreshFileList()
-			}
-		}()
-	})
+	pullButton := widget.NewButton("Pull", gleamApp.confirmPull)
 	pullButton.Icon = theme.MoveDownIcon()
 
-	pushButton := widget.NewButton("Push", func() {
-		progress := dialog.NewProgress("Pushing", "Pushing changes to remote...", window)
-		progress.Show()
-		go func() {
-			err := gleamApp.git.Push()
-			progress.Hide()
-			if err != nil {
-				dialog.ShowError(err, window)
-			} else {
-				dialog.ShowInformation("Success", "Changes pushed successfully", window)
-			}
-		}()
-	})
+	pushButton := widget.NewButton("Push", gleamApp.confirmPush)
 	pushButton.Icon = theme.UploadIcon()
-	toolbar := container.New(layout.NewHBoxLayout(), layout.NewSpacer(), layout.NewSpacer(), layout.NewSpacer(), fetchButton, pullButton, pushButton)
+
+	undoButton := widget.NewButton("Undo", gleamApp.undoLast)
+	undoButton.Icon = theme.ContentUndoIcon()
+
+	compareButton := widget.NewButton("Compare", gleamApp.showCompareWindow)
+	compareButton.Icon = theme.ContentCopyIcon()
+
+	remoteButton := widget.NewButton("", nil)
+	remoteButton.Icon = theme.MoreHorizontalIcon()
+	remoteButton.OnTapped = func() {
+		pos := gleamApp.fyneApp.Driver().AbsolutePositionForObject(remoteButton)
+		gleamApp.showRemoteMenu(pos.AddXY(0, remoteButton.Size().Height))
+	}
+	consoleButton := widget.NewButton("", gleamApp.toggleConsole)
+	consoleButton.Icon = theme.ComputerIcon()
+
+	preferencesButton := widget.NewButton("", gleamApp.showPreferences)
+	preferencesButton.Icon = theme.SettingsIcon()
+
+	toolbar := container.New(layout.NewHBoxLayout(), layout.NewSpacer(), layout.NewSpacer(), layout.NewSpacer(), undoButton, compareButton, fetchButton, pullButton, pushButton, remoteButton, consoleButton, preferencesButton)
 	gleamApp.ui.toolbar = toolbar
 
 	return gleamApp
@@ -141,86 +227,185 @@ func NewGleamApp() *GleamApp {
 func (app *GleamApp) handleCommit() {
 	defer app.logTiming("Commit handling")()
 
-	if app.ui.summary.Text != "" {
-		message := app.ui.summary.Text
-		if app.ui.description.Text != "" {
-			message += "\n\n" + app.ui.description.Text
-		}
+	message := app.ui.summary.Text
+	if app.ui.description.Text != "" {
+		message += "\n\n" + app.ui.description.Text
+	}
+	if git.HasErrors(git.LintMessage(message, app.currentSettings().SummaryLimit)) {
+		return
+	}
 
-		allFiles := app.state.files.staged
-		allFiles = append(allFiles, app.state.files.unstaged...)
-		filesToCommit := make([]string, 0)
-		for _, file := range allFiles {
-			if !slices.Contains(app.state.files.ignored, file) {
-				filesToCommit = append(filesToCommit, file)
-			}
+	app.mutex.RLock()
+	allFiles := slices.Concat(app.state.files.staged, app.state.files.unstaged)
+	filesToCommit := make([]string, 0)
+	for _, file := range allFiles {
+		if !slices.Contains(app.state.files.ignored, file) {
+			filesToCommit = append(filesToCommit, file)
 		}
+	}
+	settings := app.state.settings
+	app.mutex.RUnlock()
 
-		progress := dialog.NewProgress("Committing", "Committing changes...", app.ui.window)
-		progress.Show()
+	undoDescription := "the commit “" + app.ui.summary.Text + "”"
+	progress := dialog.NewProgress("Committing", "Committing changes...", app.ui.window)
+	progress.Show()
 
-		var wg sync.WaitGroup
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			app.git.Add(filesToCommit)
-			if err := app.git.Commit(message); err != nil {
-				progress.Hide()
-				dialog.ShowError(err, app.ui.window)
-				return
-			}
-			progress.Hide()
+	app.tasks.Serial(func(context.Context) error {
+		if err := app.recordUndo(undoDescription, git.SnapshotOptions{}); err != nil {
+			return err
+		}
+		if err := app.backend.Stage(filesToCommit); err != nil {
+			return err
+		}
+		return app.backend.Commit(message, git.CommitOptions{SignOff: settings.SignOff})
+	}, func(err error) {
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+			return
+		}
+		if settings.NotifySuccess {
 			dialog.ShowInformation("Success", "Changes committed successfully", app.ui.window)
-		}()
-		wg.Wait()
-
-		wg.Add(2)
-		go func() {
-			defer wg.Done()
-			app.refreshDiffView()
-		}()
-		go func() {
-			defer wg.Done()
-			app.refreshFileList()
-		}()
-		wg.Wait()
+		}
 
 		app.ui.summary.SetText("")
 		app.ui.description.SetText("")
-	}
+		app.refreshFileList()
+		app.refreshDiffView()
+	})
 }
 
+// refreshDiffView loads the content of the diff pane for the active file in
+// the background. A newer refresh cancels an older one that is still loading
 func (app *GleamApp) refreshDiffView() {
-	defer app.logTiming("Diff refresh")()
+	app.mutex.RLock()
+	file := app.state.activeFileDiff
+	mode := app.state.viewMode
+	opts := app.state.diffOptions
+	blame := app.state.blame
+	app.mutex.RUnlock()
+
+	if mode == viewModeBlame {
+		app.refreshBlameView(file, blame.path, blame.revision)
+		return
+	}
+	if file == "" {
+		return
+	}
+
+	task.Latest(app.tasks, diffPaneTask, func(ctx context.Context) (func() fyne.CanvasObject, error) {
+		defer app.logTiming("Diff refresh")()
+		backend := git.WithContext(app.backend, ctx)
+
+		if submodule, ok := app.submodule(file); ok {
+			change := backend.DiffSubmodule(submodule)
+			return func() fyne.CanvasObject {
+				return submoduleDiffView(change)
+			}, nil
+		}
+		if isImageFile(file) {
+			if view, ok := app.loadImageDiff(backend, file); ok {
+				return view, nil
+			}
+		}
+
+		limited := opts
+		limited.MaxLines, limited.MaxBytes = largeDiffLines, largeDiffBytes
+		diff, err := backend.DiffFile(file, limited)
+		if err != nil {
+			return nil, err
+		}
+		if git.HasLFSPointer(diff.Patch) || diff.Binary && app.isLFSFile(file) {
+			if change, ok, err := backend.DiffLFS(file); err == nil && ok {
+				return func() fyne.CanvasObject {
+					return lfsDiffView(change)
+				}, nil
+			}
+		}
+		if diff.Binary {
+			return func() fyne.CanvasObject {
+				return binaryDiffSummary(diff)
+			}, nil
+		}
+		if diff.TooLarge {
+			return func() fyne.CanvasObject {
+				return app.largeDiffPlaceholder(diff, opts)
+			}, nil
+		}
+		return func() fyne.CanvasObject {
+			return newLazyDiffView(diff.Patch, opts.WordDiff, app.tabWidth, app.showDiffViewer)
+		}, nil
+	}, app.showDiffPaneContent)
+}
 
-	diff, err := app.git.GetFileDiff(app.state.activeFileDiff)
+// showDiffViewer makes view the diff that navigation and search apply to
+func (app *GleamApp) showDiffViewer(view *DiffView) {
+	app.ui.diffViewer = view
+	app.applySearch()
+}
+
+// largeDiffPlaceholder shows the sizes of a diff that was too large to load
+// with the diff pane, and loads it in the background when asked to
+func (app *GleamApp) largeDiffPlaceholder(diff git.FileDiff, opts git.DiffOptions) fyne.CanvasObject {
+	message := fmt.Sprintf("The diff of %s is large (%s before, %s after) and is not shown automatically.",
+		diff.Path, formatFileSize(diff.OldSize), formatFileSize(diff.NewSize))
+	return largeDiffPlaceholder(message, func(show func(fyne.CanvasObject)) {
+		task.Latest(app.tasks, diffPaneTask, func(ctx context.Context) (string, error) {
+			full, err := git.WithContext(app.backend, ctx).DiffFile(diff.Path, opts)
+			return full.Patch, err
+		}, func(patch string, err error) {
+			if err != nil {
+				dialog.ShowError(err, app.ui.window)
+				return
+			}
+			view := newDiffView(patch, opts.WordDiff, app.tabWidth)
+			show(view)
+			app.showDiffViewer(view)
+		})
+	})
+}
+
+// showDiffPaneContent replaces the content of the diff pane. It runs on the
+// UI goroutine with the result of a diff pane task
+func (app *GleamApp) showDiffPaneContent(build func() fyne.CanvasObject, err error) {
 	if err != nil {
-		log.Printf("Error getting diff: %v", err)
+		log.Printf("Error loading diff pane: %v", err)
 		return
 	}
 
-	app.state.activeDiff = diff
-	app.ui.diffViewer = highlightDiff(diff)
-	app.ui.diffContainer.Objects[0] = container.NewScroll(app.ui.diffViewer)
-	app.ui.diffViewer.Refresh()
+	app.ui.diffViewer = nil
+	app.ui.diffContainer.Objects[0] = build()
+	app.ui.diffContainer.Refresh()
 }
 
 func (app *GleamApp) updateFileCache() error {
-	app.mutex.Lock()
-	defer app.mutex.Unlock()
-
-	stagedFiles, err := app.git.GetStagedFiles()
+	stagedFiles, err := app.backend.GetStagedFiles()
 	if err != nil {
 		return err
 	}
 
-	unstagedFiles, err := app.git.GetUnstagedFiles()
+	unstagedFiles, err := app.backend.GetUnstagedFiles()
 	if err != nil {
 		return err
 	}
 
+	// Partially staged files are listed once, with the staged files
+	unstagedFiles = slices.DeleteFunc(unstagedFiles, func(file string) bool {
+		return slices.Contains(stagedFiles, file)
+	})
+
+	submodules, err := app.backend.Submodules()
+	if err != nil {
+		log.Printf("Error listing submodules: %v", err)
+	}
+	lfsFiles := app.lfsTrackedFiles(slices.Concat(stagedFiles, unstagedFiles))
+
+	app.mutex.Lock()
 	app.state.files.staged = stagedFiles
 	app.state.files.unstaged = unstagedFiles
+	app.state.submodules = submodules
+	app.state.lfsFiles = lfsFiles
+	app.mutex.Unlock()
 
 	log.Printf("Staged files (%d): %v", len(stagedFiles), stagedFiles)
 	log.Printf("Unstaged files (%d): %v", len(unstagedFiles), unstagedFiles)
@@ -228,24 +413,26 @@ func (app *GleamApp) updateFileCache() error {
 	return nil
 }
 
+// refreshFileList reloads the changed files. It is queued behind running
+// index operations so it always sees their result
 func (app *GleamApp) refreshFileList() {
-	defer app.logTiming("File list refresh")()
-
-	go func() {
-		if err := app.updateFileCache(); err != nil {
+	app.tasks.Serial(func(context.Context) error {
+		defer app.logTiming("File list refresh")()
+		return app.updateFileCache()
+	}, func(err error) {
+		if err != nil {
 			log.Printf("Error updating file cache: %v", err)
 		}
-	}()
-
-	if app.ui.fileList != nil {
-		app.ui.fileList.Refresh()
-	}
+		if app.ui.fileList != nil {
+			app.ui.fileList.Refresh()
+		}
+	})
 }
 
 func (app *GleamApp) createFileList() fyne.CanvasObject {
 	defer app.logTiming("File list creation")()
 
-	go app.updateFileCache()
+	app.refreshFileList()
 
 	getFileCount := func() int {
 		app.mutex.RLock()
@@ -261,7 +448,7 @@ func (app *GleamApp) createFileList() fyne.CanvasObject {
 		app.mutex.RLock()
 		defer app.mutex.RUnlock()
 
-		allFiles := append(app.state.files.staged, app.state.files.unstaged...)
+		allFiles := slices.Concat(app.state.files.staged, app.state.files.unstaged)
 		if len(allFiles) == 0 || int(id) >= len(allFiles) {
 			return
 		}
@@ -270,8 +457,17 @@ func (app *GleamApp) createFileList() fyne.CanvasObject {
 		fileItem := item.(*FileListItem)
 		isIgnored := slices.Contains(app.state.files.ignored, currentFile)
 
+		// Detach the handler of the previously bound file before updating
+		// the check, or it would fire while the read lock is held
+		fileItem.check.OnChanged = nil
 		fileItem.check.SetChecked(!isIgnored)
 		fileItem.label.SetText(currentFile)
+		if index := slices.IndexFunc(app.state.submodules, func(s git.Submodule) bool { return s.Path == currentFile }); index >= 0 {
+			fileItem.label.SetText(currentFile + " (submodule " + submoduleState(app.state.submodules[index]) + ")")
+		}
+		if app.state.lfsFiles[currentFile] {
+			fileItem.label.SetText(currentFile + " (LFS)")
+		}
 
 		fileItem.check.OnChanged = func(checked bool) {
 			app.mutex.Lock()
@@ -286,12 +482,10 @@ func (app *GleamApp) createFileList() fyne.CanvasObject {
 
 		fileItem.onClick = func(e *desktop.MouseEvent) {
 			if e.Button == desktop.MouseButtonSecondary {
-				app.showPopupMenu(e.AbsolutePosition)
+				app.showPopupMenu(e.AbsolutePosition, currentFile)
 				return
 			}
-			log.Printf("Selected file: %s", currentFile)
-			app.state.activeFileDiff = currentFile
-			go app.refreshDiffView()
+			app.selectFile(currentFile)
 		}
 	}
 
@@ -307,6 +501,66 @@ func (app *GleamApp) createFileList() fyne.CanvasObject {
 	)
 }
 
+// selectFile shows file in the diff pane
+func (app *GleamApp) selectFile(file string) {
+	log.Printf("Selected file: %s", file)
+	app.mutex.Lock()
+	app.state.activeFileDiff = file
+	app.state.blame.path = file
+	app.state.blame.revision = ""
+	app.mutex.Unlock()
+	app.refreshDiffView()
+}
+
+// selectedFile returns the file shown in the diff pane
+func (app *GleamApp) selectedFile() string {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	return app.state.activeFileDiff
+}
+
+// moveFileSelection selects the file delta rows below the selected one
+func (app *GleamApp) moveFileSelection(delta int) {
+	app.mutex.RLock()
+	allFiles := slices.Concat(app.state.files.staged, app.state.files.unstaged)
+	index := slices.Index(allFiles, app.state.activeFileDiff)
+	app.mutex.RUnlock()
+	if len(allFiles) == 0 {
+		return
+	}
+
+	if index < 0 {
+		index = 0
+	} else {
+		index = min(max(index+delta, 0), len(allFiles)-1)
+	}
+	app.ui.fileList.Select(index)
+	app.ui.fileList.ScrollTo(index)
+	app.selectFile(allFiles[index])
+}
+
+// stageSelected stages or unstages the file shown in the diff pane
+func (app *GleamApp) stageSelected(stage bool) {
+	file := app.selectedFile()
+	if file == "" {
+		return
+	}
+
+	app.tasks.Serial(func(context.Context) error {
+		if stage {
+			return app.backend.Stage([]string{file})
+		}
+		return app.backend.Unstage([]string{file})
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+			return
+		}
+		app.refreshFileList()
+		app.refreshDiffView()
+	})
+}
+
 func removeFromSlice(slice []string, item string) []string {
 	for i, v := range slice {
 		if v == item {
@@ -316,11 +570,11 @@ func removeFromSlice(slice []string, item string) []string {
 	return slice
 }
 
-func (app *GleamApp) createCommitUI() (*widget.Entry, *widget.Entry, *widget.Button, *fyne.Container) {
-	summaryEntry := widget.NewEntry()
+func (app *GleamApp) createCommitUI() (*shortcutEntry, *shortcutEntry, *widget.Button, *fyne.Container) {
+	summaryEntry := newShortcutEntry(app.keymap, false)
 	summaryEntry.SetPlaceHolder("Summary (required)")
 
-	descriptionEntry := widget.NewMultiLineEntry()
+	descriptionEntry := newShortcutEntry(app.keymap, true)
 	descriptionEntry.SetMinRowsVisible(5)
 	descriptionEntry.SetPlaceHolder("Description")
 
@@ -338,14 +592,26 @@ func (app *GleamApp) createCommitUI() (*widget.Entry, *widget.Entry, *widget.But
 		log.Printf("AI Button clicked")
 	}
 
+	summaryHint := widget.NewLabel("")
+	summaryHint.Importance = widget.WarningImportance
+
 	summaryEntry.OnChanged = func(text string) {
 		if text == "" {
 			commitButton.Disable()
 		} else {
 			commitButton.Enable()
 		}
+
+		// The empty summary is already shown by the disabled button
+		hints := make([]string, 0)
+		for _, issue := range git.LintMessage(text, app.currentSettings().SummaryLimit) {
+			if !issue.Error {
+				hints = append(hints, issue.Text)
+			}
+		}
+		summaryHint.SetText(strings.Join(hints, ", "))
 	}
-	actionBar := container.New(layout.NewHBoxLayout(), layout.NewSpacer(), layout.NewSpacer(), layout.NewSpacer(), commitSuggestionButton)
+	actionBar := container.New(layout.NewHBoxLayout(), summaryHint, layout.NewSpacer(), layout.NewSpacer(), layout.NewSpacer(), commitSuggestionButton)
 
 	app.ui.summary = summaryEntry
 	app.ui.description = descriptionEntry
@@ -354,26 +620,36 @@ func (app *GleamApp) createCommitUI() (*widget.Entry, *widget.Entry, *widget.But
 	return summaryEntry, descriptionEntry, commitButton, actionBar
 }
 
-func logLifecycle(fyneApp fyne.App, app *GleamApp) {
-	lifecycle := fyneApp.Lifecycle()
+// activate applies the settings of the repository when its tab is selected
+// and reloads its changes
+func (app *GleamApp) activate() {
+	app.appliedSettings = nil
+	app.applySettings()
+	app.refreshFileList()
+	app.refreshDiffView()
+}
 
-	lifecycle.SetOnStarted(func() {
-		log.Println("Lifecycle: Started")
-	})
-	lifecycle.SetOnStopped(func() {
-		log.Println("Lifecycle: Stopped")
-	})
-	lifecycle.SetOnEnteredForeground(func() {
-		log.Println("Lifecycle: Entered Foreground")
-		app.refreshFileList()
-		go app.refreshDiffView()
-	})
-	lifecycle.SetOnExitedForeground(func() {
-		log.Println("Lifecycle: Exited Foreground")
-	})
+// Close stops the background work of the repository. Results of work that
+// is still running are dropped, and git processes are killed. It waits for
+// the work to return, so the UI calls it from another goroutine
+func (app *GleamApp) Close() {
+	app.updates.close()
+	if window := app.ui.console.window; window != nil {
+		window.SetOnClosed(nil)
+		window.Close()
+	}
+	app.scheduleAutoFetch(0)
+	app.tasks.Close()
+	app.stopAskpass()
 }
 
-func (app *GleamApp) Run() {
+// Name is the name shown for the repository
+func (app *GleamApp) Name() string {
+	return filepath.Base(app.git.WorkingDir)
+}
+
+// Content builds the user interface of the repository
+func (app *GleamApp) Content() fyne.CanvasObject {
 	defer app.logTiming("App initialization")()
 
 	summaryEntry, descriptionEntry, commitButton, actionBar := app.createCommitUI()
@@ -385,19 +661,25 @@ func (app *GleamApp) Run() {
 		app.createFileList(),
 	)
 
-	diffViewer := highlightDiff(app.state.activeDiff)
+	diffViewer := NewDiffView(app.state.activeDiff)
 	app.ui.diffViewer = diffViewer
-	app.ui.diffContainer = container.NewStack(container.NewScroll(diffViewer))
+	app.ui.diffContainer = container.NewStack(diffViewer)
+
+	viewModeSelect := widget.NewRadioGroup([]string{viewModeDiff, viewModeBlame}, nil)
+	viewModeSelect.Horizontal = true
+	viewModeSelect.Required = true
+	viewModeSelect.SetSelected(app.state.viewMode)
+	viewModeSelect.OnChanged = app.setViewMode
+	app.ui.viewMode = viewModeSelect
+	diffHeader := container.NewHBox(viewModeSelect, app.createDiffNavigation(), app.createDiffOptionsBar())
+	diffPane := container.NewBorder(container.NewHScroll(diffHeader), app.createFindBar(), nil, nil, app.ui.diffContainer)
 
 	topBar := container.NewHBox(app.ui.toolbar)
-	mainContent := container.NewHSplit(commitField, app.ui.diffContainer)
+	mainContent := container.NewHSplit(commitField, diffPane)
 	mainContent.Offset = 0.35
 
-	verticalLayout := container.NewBorder(topBar, nil, nil, nil, mainContent)
-
-	app.ui.window.SetContent(verticalLayout)
-	app.ui.window.Resize(fyne.NewSize(1200, 600))
-	app.ui.window.CenterOnScreen()
+	app.ui.console.main = mainContent
+	app.ui.console.dock = container.NewStack(mainContent)
 
-	app.ui.window.ShowAndRun()
+	return container.NewBorder(topBar, nil, nil, nil, app.ui.console.dock)
 }
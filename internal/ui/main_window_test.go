@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"context"
+	"image/color"
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/alecthomas/chroma/v2/lexers"
+
+	"gleam/internal/git"
+	"gleam/internal/themes"
+)
+
+// newTestGleamApp opens the repository of backend in a window of Fyne's test
+// app and waits for the file list to be filled in
+func newTestGleamApp(t *testing.T, backend git.Backend) *GleamApp {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	testApp := test.NewTempApp(t)
+	window := testApp.NewWindow("Gleam")
+	workspace := &Workspace{app: testApp, window: window}
+
+	app := newGleamApp(testApp, window, newKeymap(workspace), git.NewGitCommand(t.TempDir()), backend)
+	t.Cleanup(app.Close)
+	window.SetContent(app.Content())
+	window.Resize(fyne.NewSize(1000, 700))
+	waitForTasks(app)
+	return app
+}
+
+// waitForTasks returns once the serial tasks queued so far have finished and
+// their results have been applied
+func waitForTasks(app *GleamApp) {
+	done := make(chan struct{})
+	app.tasks.Serial(func(context.Context) error { return nil }, func(error) { close(done) })
+	<-done
+}
+
+// fileListItem returns row id of the file list as the list would show it
+func fileListItem(app *GleamApp, id widget.ListItemID) *FileListItem {
+	item := app.ui.fileList.CreateItem()
+	app.ui.fileList.UpdateItem(id, item)
+	return item.(*FileListItem)
+}
+
+// newChangedBackend returns a repository with a modified file and an
+// untracked one
+func newChangedBackend(t *testing.T) *git.FakeBackend {
+	t.Helper()
+	backend := git.NewFakeBackend()
+	backend.WriteFile("a.txt", "first\n")
+	mustSucceed(t, backend.Stage([]string{"a.txt"}))
+	mustSucceed(t, backend.Commit("Initial commit", git.CommitOptions{}))
+	backend.WriteFile("a.txt", "second\n")
+	backend.WriteFile("b.txt", "new file\n")
+	return backend
+}
+
+func mustSucceed(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileListShowsChanges(t *testing.T) {
+	app := newTestGleamApp(t, newChangedBackend(t))
+
+	if got := app.ui.fileList.Length(); got != 2 {
+		t.Fatalf("file list has %d rows, want 2", got)
+	}
+	for id, want := range []string{"a.txt", "b.txt"} {
+		item := fileListItem(app, id)
+		if item.label.Text != want || !item.check.Checked {
+			t.Errorf("row %d shows %q, checked %v; want %q, checked", id, item.label.Text, item.check.Checked, want)
+		}
+	}
+
+	app.ui.fileList.Resize(fyne.NewSize(300, 100))
+	test.AssertObjectRendersToMarkup(t, "file_list.xml", app.ui.fileList)
+}
+
+func TestUncheckedFilesAreNotCommitted(t *testing.T) {
+	backend := newChangedBackend(t)
+	app := newTestGleamApp(t, backend)
+
+	test.Tap(fileListItem(app, 0).check)
+	if !slices.Equal(app.state.files.ignored, []string{"a.txt"}) {
+		t.Fatalf("unchecking a.txt ignores %v", app.state.files.ignored)
+	}
+	if fileListItem(app, 0).check.Checked {
+		t.Errorf("a.txt is still checked after a refresh of its row")
+	}
+
+	app.ui.summary.SetText("Add b")
+	app.handleCommit()
+	waitForTasks(app)
+	waitForTasks(app)
+
+	entries, err := backend.Log("HEAD", 0)
+	mustSucceed(t, err)
+	if len(entries) != 2 || entries[0].Subject != "Add b" {
+		t.Fatalf("log after commit is %+v", entries)
+	}
+	staged, _ := backend.GetStagedFiles()
+	unstaged, _ := backend.GetUnstagedFiles()
+	if len(staged) != 0 || !slices.Equal(unstaged, []string{"a.txt"}) {
+		t.Errorf("after committing only b.txt the staged files are %v and the unstaged ones %v", staged, unstaged)
+	}
+	if app.ui.summary.Text != "" {
+		t.Errorf("summary is %q after the commit", app.ui.summary.Text)
+	}
+	if !slices.Equal(app.state.files.unstaged, []string{"a.txt"}) {
+		t.Errorf("file list shows %v after the commit", app.state.files.unstaged)
+	}
+}
+
+func TestCommitRequiresSummary(t *testing.T) {
+	backend := newChangedBackend(t)
+	app := newTestGleamApp(t, backend)
+
+	for _, summary := range []string{"", "   "} {
+		app.ui.summary.SetText(summary)
+		app.ui.description.SetText("Only a description")
+		app.handleCommit()
+		waitForTasks(app)
+
+		entries, err := backend.Log("HEAD", 0)
+		mustSucceed(t, err)
+		if len(entries) != 1 {
+			t.Errorf("summary %q committed %+v", summary, entries[0])
+		}
+		if app.ui.description.Text == "" {
+			t.Errorf("summary %q cleared the description", summary)
+		}
+	}
+}
+
+func TestFileMenu(t *testing.T) {
+	app := newTestGleamApp(t, newChangedBackend(t))
+
+	var labels []string
+	for _, item := range app.fileMenu("b.txt").Items {
+		labels = append(labels, item.Label)
+	}
+	want := []string{"Discard changes", "Show history", "Track with Git LFS..."}
+	if !slices.Equal(labels, want) {
+		t.Errorf("menu of b.txt has %q, want %q", labels, want)
+	}
+
+	fileListItem(app, 1).MouseDown(&desktop.MouseEvent{Button: desktop.MouseButtonSecondary})
+	if app.ui.popup == nil || !app.ui.popup.Visible() {
+		t.Errorf("right click does not show the menu")
+	}
+}
+
+func TestHighlightDiffLine(t *testing.T) {
+	test.NewTempApp(t)
+	theme := newDiffTheme(themes.Default().Palette(true), "")
+	palette := theme.palette
+	lexer := lexers.Get("go")
+
+	lines := []string{"--- a/main.go", "@@ -1 +1 @@", "+x := 1", "-x := 2", " y := 3"}
+	kinds := []git.DiffLineKind{git.DiffLineHeader, git.DiffLineHunk, git.DiffLineAdded, git.DiffLineRemoved, git.DiffLineContext}
+	grid := widget.NewTextGrid()
+	for _, line := range lines {
+		grid.Rows = append(grid.Rows, widget.TextGridRow{Cells: make([]widget.TextGridCell, len(line))})
+	}
+	for row, line := range lines {
+		highlightDiffLine(grid, row, line, kinds[row], lexer, theme, defaultTabWidth)
+	}
+
+	rowStyles := []struct {
+		bg, fg color.Color
+	}{
+		{palette.Header, palette.HeaderText},
+		{palette.Hunk, palette.HeaderText},
+		{palette.Added, color.Transparent},
+		{palette.Removed, color.Transparent},
+	}
+	for row, want := range rowStyles {
+		style := grid.Rows[row].Style
+		if style == nil || style.BackgroundColor() != want.bg || style.TextColor() != want.fg {
+			t.Errorf("row %q is styled %+v, want background %v and text %v", lines[row], style, want.bg, want.fg)
+		}
+	}
+
+	for row, bg := range map[int]color.Color{2: palette.Added, 3: palette.Removed} {
+		cells := grid.Rows[row].Cells
+		if marker := cells[0].Style; marker == nil || marker.TextColor() != palette.Marker || marker.BackgroundColor() != bg {
+			t.Errorf("marker of %q is styled %+v", lines[row], marker)
+		}
+		for col, cell := range cells[1:] {
+			if cell.Style == nil || cell.Style.BackgroundColor() != bg {
+				t.Errorf("column %d of %q is styled %+v", col+1, lines[row], cell.Style)
+			}
+		}
+	}
+
+	context := grid.Rows[4]
+	if context.Style != nil {
+		t.Errorf("context line has row style %+v", context.Style)
+	}
+	for col, cell := range context.Cells {
+		if cell.Style == nil || cell.Style.BackgroundColor() != color.Transparent {
+			t.Errorf("column %d of the context line is styled %+v", col, cell.Style)
+		}
+	}
+}
+
+// newUnclosedGleamApp is newTestGleamApp for tests that close the app
+func newUnclosedGleamApp(t *testing.T) *GleamApp {
+	t.Helper()
+	testApp := test.NewTempApp(t)
+	window := testApp.NewWindow("Gleam")
+	return newGleamApp(testApp, window, newKeymap(&Workspace{app: testApp, window: window}), git.NewGitCommand(t.TempDir()), git.NewFakeBackend())
+}
+
+func TestUpdatesAfterCloseAreDropped(t *testing.T) {
+	app := newUnclosedGleamApp(t)
+	app.Close()
+
+	done := make(chan struct{})
+	go func() {
+		app.runOnUI(func() { t.Error("update ran after Close") })
+		close(done)
+	}()
+	<-done
+}
+
+func TestCloseCancelsRunningWork(t *testing.T) {
+	app := newUnclosedGleamApp(t)
+
+	started := make(chan struct{})
+	app.tasks.Go(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, func(error) { t.Error("result of canceled work was applied") })
+	<-started
+
+	// Close would wait forever for work that is not canceled
+	app.Close()
+}
@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+const (
+	scopeGlobal     = "All repositories"
+	scopeRepository = "This repository"
+)
+
+var (
+	textSizeLabels = []string{"Default", "11", "12", "13", "14", "16", "18", "20"}
+	textSizeValues = []float32{0, 11, 12, 13, 14, 16, 18, 20}
+
+	autoFetchLabels = []string{"Off", "Every 5 minutes", "Every 15 minutes", "Every 30 minutes", "Every hour"}
+	autoFetchValues = []int{0, 5, 15, 30, 60}
+
+	backendLabels = []string{"Git command line", "Built-in (go-git) for status and history"}
+	backendValues = []string{git.BackendExec, git.BackendGoGit}
+)
+
+// showPreferences opens the preferences window. Values edited with the
+// repository scope selected are stored as overrides for the current
+// repository, the others as global defaults
+func (app *GleamApp) showPreferences() {
+	window := app.fyneApp.NewWindow("Preferences")
+	content := container.NewStack()
+
+	var scope *widget.RadioGroup
+	var show func()
+	show = func() {
+		content.Objects = []fyne.CanvasObject{app.createPreferenceTabs(window, scope.Selected == scopeRepository, func() {
+			app.resetRepositorySettings()
+			show()
+		})}
+		content.Refresh()
+	}
+	scope = widget.NewRadioGroup([]string{scopeGlobal, scopeRepository}, func(string) { show() })
+	scope.Horizontal = true
+	scope.Required = true
+	scope.SetSelected(scopeGlobal)
+
+	window.SetContent(container.NewBorder(container.NewHBox(scope), nil, nil, nil, content))
+	window.Resize(fyne.NewSize(640, 480))
+	window.Show()
+}
+
+// preferenceEditor creates widgets that save their value in one scope
+type preferenceEditor struct {
+	app  *GleamApp
+	repo bool
+}
+
+func (e preferenceEditor) check(label, key string, value bool) *widget.Check {
+	check := widget.NewCheck(label, nil)
+	check.SetChecked(value)
+	check.OnChanged = func(checked bool) { e.app.setSetting(e.repo, key, checked) }
+	return check
+}
+
+func (e preferenceEditor) number(key string, value, minimum int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(value))
+	entry.Validator = func(text string) error {
+		n, err := strconv.Atoi(text)
+		if err != nil || n < minimum {
+			return fmt.Errorf("enter a number of at least %d", minimum)
+		}
+		return nil
+	}
+	entry.OnChanged = func(text string) {
+		if entry.Validate() == nil {
+			n, _ := strconv.Atoi(text)
+			e.app.setSetting(e.repo, key, n)
+		}
+	}
+	return entry
+}
+
+func (e preferenceEditor) text(key, value, placeholder string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeholder)
+	entry.SetText(value)
+	entry.OnChanged = func(text string) { e.app.setSetting(e.repo, key, text) }
+	return entry
+}
+
+// choice maps the selected label to the value at the same index
+func choice[T comparable](e preferenceEditor, key string, labels []string, values []T, value T) *widget.Select {
+	sel := widget.NewSelect(labels, nil)
+	for i, v := range values {
+		if v == value {
+			sel.SetSelectedIndex(i)
+		}
+	}
+	sel.OnChanged = func(string) {
+		if i := sel.SelectedIndex(); i >= 0 {
+			e.app.setSetting(e.repo, key, values[i])
+		}
+	}
+	return sel
+}
+
+// createPreferenceTabs shows the settings of one scope. In the repository
+// scope, reset is offered to drop the overrides
+func (app *GleamApp) createPreferenceTabs(window fyne.Window, repo bool, reset func()) fyne.CanvasObject {
+	settings := mergeSettings(defaultSettings(), loadSettingsLayer(app.fyneApp.Preferences(), ""))
+	if repo {
+		settings = app.currentSettings()
+	}
+	e := preferenceEditor{app: app, repo: repo}
+
+	general := widget.NewForm(
+		widget.NewFormItem("Confirm", container.NewVBox(
+			e.check("Before pushing", settingConfirmPush, settings.ConfirmPush),
+			e.check("Before pulling", settingConfirmPull, settings.ConfirmPull),
+		)),
+		widget.NewFormItem("Messages", e.check("Report successful commits and pushes", settingNotifySuccess, settings.NotifySuccess)),
+	)
+
+	diff := widget.NewForm(
+		widget.NewFormItem("Default mode", choice(e, settingDefaultViewMode,
+			[]string{viewModeDiff, viewModeBlame}, []string{viewModeDiff, viewModeBlame}, settings.DefaultViewMode)),
+		widget.NewFormItem("Tab width", e.number(settingTabWidth, settings.TabWidth, 1)),
+	)
+
+	commit := widget.NewForm(
+		widget.NewFormItem("Summary length", e.number(settingSummaryLimit, settings.SummaryLimit, 0)),
+		widget.NewFormItem("", widget.NewLabel("Warn when the summary is longer, 0 turns the warning off")),
+		widget.NewFormItem("Sign-off", e.check("Add a Signed-off-by trailer", settingSignOff, settings.SignOff)),
+	)
+
+	gitPath := e.text(settingGitPath, settings.GitPath, "git")
+	browseGit := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(file fyne.URIReadCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+			file.Close()
+			gitPath.SetText(file.URI().Path())
+		}, window)
+	})
+	gitSection := widget.NewForm(
+		widget.NewFormItem("Git binary", container.NewBorder(nil, nil, nil, browseGit, gitPath)),
+		widget.NewFormItem("Backend", choice(e, settingBackend, backendLabels, backendValues, settings.Backend)),
+		widget.NewFormItem("", widget.NewLabel("Both take effect after reopening the repository")),
+		widget.NewFormItem("Auto-fetch", choice(e, settingAutoFetchMinutes, autoFetchLabels, autoFetchValues, settings.AutoFetchMinutes)),
+	)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("General", general),
+		container.NewTabItem("Diff", diff),
+		container.NewTabItem("Commit", commit),
+		container.NewTabItem("Git", gitSection),
+		container.NewTabItem("Appearance", app.createAppearancePreferences(window, e, settings)),
+		container.NewTabItem("Shortcuts", app.createShortcutPreferences(e)),
+	)
+
+	if !repo {
+		return tabs
+	}
+	info := widget.NewLabel("Values changed here only apply to " + app.git.WorkingDir)
+	info.Wrapping = fyne.TextWrapWord
+	resetButton := widget.NewButton("Use global defaults", reset)
+	return container.NewBorder(container.NewBorder(nil, nil, nil, resetButton, info), nil, nil, nil, tabs)
+}
+
+// createAppearancePreferences holds the settings that always apply to all
+// repositories
+func (app *GleamApp) createAppearancePreferences(window fyne.Window, e preferenceEditor, settings Settings) fyne.CanvasObject {
+	if e.repo {
+		return widget.NewLabel("Appearance settings apply to all repositories.")
+	}
+
+	font := e.text(settingMonospaceFont, settings.MonospaceFont, "Built-in monospace font")
+	browseFont := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+			file.Close()
+			font.SetText(file.URI().Path())
+		}, window)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".ttf", ".otf"}))
+		open.Show()
+	})
+
+	fonts := widget.NewForm(
+		widget.NewFormItem("Code font", container.NewBorder(nil, nil, nil, browseFont, font)),
+		widget.NewFormItem("Text size", choice(e, settingTextSize, textSizeLabels, textSizeValues, settings.TextSize)),
+	)
+	return container.NewVScroll(container.NewVBox(app.createThemeSettings(), fonts))
+}
+
+// createShortcutPreferences lists the commands with their key bindings. A
+// binding is saved as soon as it is valid and not taken by another command
+func (app *GleamApp) createShortcutPreferences(e preferenceEditor) fyne.CanvasObject {
+	if e.repo {
+		return widget.NewLabel("Keyboard shortcuts apply to all repositories.")
+	}
+
+	form := widget.NewForm()
+	for _, cmd := range app.keymap.commands {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder("Not bound")
+		entry.SetText(app.keymap.binding(cmd))
+		entry.Validator = func(text string) error {
+			return app.keymap.validate(cmd, text)
+		}
+		entry.OnChanged = func(text string) {
+			if entry.Validate() == nil {
+				app.keymap.setBinding(cmd, text)
+			}
+		}
+		reset := widget.NewButtonWithIcon("", theme.ContentUndoIcon(), func() {
+			entry.SetText(cmd.defaultKey)
+		})
+		form.Append(cmd.title, container.NewBorder(nil, nil, nil, reset, entry))
+	}
+
+	help := widget.NewLabel("Combine Shortcut (Ctrl, or Cmd on macOS), Ctrl, Alt, Shift and Super with a key, for example Shortcut+Shift+P or F3.")
+	help.Wrapping = fyne.TextWrapWord
+	return container.NewBorder(help, nil, nil, nil, container.NewVScroll(form))
+}
@@ -0,0 +1,238 @@
+package ui
+
+import (
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	recentPreferenceKey  = "recentRepositories"
+	sessionPreferenceKey = "session"
+
+	// maxRecentRepositories bounds the unpinned entries. Pinned repositories
+	// are always kept
+	maxRecentRepositories = 15
+)
+
+// recentRepository is an entry of the most recently used list
+type recentRepository struct {
+	Path   string    `json:"path"`
+	Pinned bool      `json:"pinned"`
+	Opened time.Time `json:"opened"`
+}
+
+// session is the state restored on the next start
+type session struct {
+	Repositories []sessionRepository `json:"repositories"`
+	Active       int                 `json:"active"`
+	Width        float32             `json:"width"`
+	Height       float32             `json:"height"`
+}
+
+type sessionRepository struct {
+	Path         string `json:"path"`
+	SelectedFile string `json:"selectedFile"`
+}
+
+// loadRecentRepositories returns the pinned repositories first and the
+// others by when they were last opened
+func loadRecentRepositories() []recentRepository {
+	var recent []recentRepository
+	saved := fyne.CurrentApp().Preferences().String(recentPreferenceKey)
+	if saved != "" {
+		if err := json.Unmarshal([]byte(saved), &recent); err != nil {
+			log.Printf("Error loading recent repositories: %v", err)
+		}
+	}
+
+	slices.SortStableFunc(recent, func(a, b recentRepository) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		return b.Opened.Compare(a.Opened)
+	})
+	return recent
+}
+
+func saveRecentRepositories(recent []recentRepository) {
+	data, err := json.Marshal(recent)
+	if err != nil {
+		log.Printf("Error saving recent repositories: %v", err)
+		return
+	}
+	fyne.CurrentApp().Preferences().SetString(recentPreferenceKey, string(data))
+}
+
+// updateRecentRepository changes the entry of path, adding it if needed,
+// and drops the oldest unpinned entries beyond the limit
+func updateRecentRepository(path string, update func(entry *recentRepository)) {
+	recent := loadRecentRepositories()
+	index := slices.IndexFunc(recent, func(entry recentRepository) bool { return entry.Path == path })
+	if index < 0 {
+		recent = append(recent, recentRepository{Path: path})
+		index = len(recent) - 1
+	}
+	update(&recent[index])
+
+	slices.SortStableFunc(recent, func(a, b recentRepository) int {
+		return b.Opened.Compare(a.Opened)
+	})
+	kept := make([]recentRepository, 0, len(recent))
+	unpinned := 0
+	for _, entry := range recent {
+		if !entry.Pinned {
+			if unpinned == maxRecentRepositories {
+				continue
+			}
+			unpinned++
+		}
+		kept = append(kept, entry)
+	}
+	saveRecentRepositories(kept)
+}
+
+func touchRecentRepository(path string) {
+	updateRecentRepository(path, func(entry *recentRepository) {
+		entry.Opened = time.Now()
+	})
+}
+
+func setRepositoryPinned(path string, pinned bool) {
+	updateRecentRepository(path, func(entry *recentRepository) {
+		entry.Pinned = pinned
+	})
+}
+
+func removeRecentRepository(path string) {
+	recent := slices.DeleteFunc(loadRecentRepositories(), func(entry recentRepository) bool {
+		return entry.Path == path
+	})
+	saveRecentRepositories(recent)
+}
+
+// showRecentMenu lists the recent repositories below the Recent button
+func (w *Workspace) showRecentMenu(pos fyne.Position) {
+	items := make([]*fyne.MenuItem, 0)
+	for _, entry := range loadRecentRepositories() {
+		label := filepath.Base(entry.Path) + "  —  " + entry.Path
+		item := fyne.NewMenuItem(label, func() {
+			w.openOrShowError(entry.Path)
+		})
+		if entry.Pinned {
+			item.Icon = theme.ConfirmIcon()
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		empty := fyne.NewMenuItem("No recent repositories", nil)
+		empty.Disabled = true
+		items = append(items, empty)
+	}
+	items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Manage…", w.showRecentRepositories))
+
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("Recent", items...), w.window.Canvas(), pos)
+}
+
+// showRecentRepositories lets the user open, pin and forget recent
+// repositories
+func (w *Workspace) showRecentRepositories() {
+	recent := loadRecentRepositories()
+
+	var list *widget.List
+	reload := func() {
+		recent = loadRecentRepositories()
+		list.Refresh()
+	}
+
+	var manager dialog.Dialog
+	list = widget.NewList(
+		func() int { return len(recent) },
+		func() fyne.CanvasObject {
+			pin := widget.NewCheck("Pinned", nil)
+			open := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), nil)
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil, container.NewHBox(pin, open, remove), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := recent[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(entry.Path)
+
+			actions := row.Objects[1].(*fyne.Container)
+			pin := actions.Objects[0].(*widget.Check)
+			pin.OnChanged = nil
+			pin.SetChecked(entry.Pinned)
+			pin.OnChanged = func(pinned bool) {
+				setRepositoryPinned(entry.Path, pinned)
+				reload()
+			}
+			actions.Objects[1].(*widget.Button).OnTapped = func() {
+				manager.Hide()
+				w.openOrShowError(entry.Path)
+			}
+			actions.Objects[2].(*widget.Button).OnTapped = func() {
+				removeRecentRepository(entry.Path)
+				reload()
+			}
+		},
+	)
+
+	manager = dialog.NewCustom("Recent repositories", "Close", list, w.window)
+	manager.Resize(fyne.NewSize(700, 400))
+	manager.Show()
+}
+
+func loadSession() (session, bool) {
+	var saved session
+	data := fyne.CurrentApp().Preferences().String(sessionPreferenceKey)
+	if data == "" {
+		return saved, false
+	}
+	if err := json.Unmarshal([]byte(data), &saved); err != nil {
+		log.Printf("Error loading session: %v", err)
+		return saved, false
+	}
+	return saved, true
+}
+
+// saveSession remembers the open repositories, their selected files, the
+// selected tab and the window size. Fyne does not expose the window
+// position, so it is not kept
+func (w *Workspace) saveSession() {
+	w.mutex.RLock()
+	saved := session{
+		Repositories: make([]sessionRepository, len(w.repos)),
+		Active:       w.tabs.SelectedIndex(),
+	}
+	for i, repo := range w.repos {
+		saved.Repositories[i] = sessionRepository{Path: repo.git.WorkingDir, SelectedFile: repo.selectedFile()}
+	}
+	w.mutex.RUnlock()
+
+	// The window has no size before it is shown, keep the saved one then
+	size := w.window.Canvas().Size()
+	if size.IsZero() {
+		previous, _ := loadSession()
+		size = fyne.NewSize(previous.Width, previous.Height)
+	}
+	saved.Width, saved.Height = size.Width, size.Height
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		log.Printf("Error saving session: %v", err)
+		return
+	}
+	fyne.CurrentApp().Preferences().SetString(sessionPreferenceKey, string(data))
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+	"gleam/internal/task"
+)
+
+// reflogLimit is how many previous positions of HEAD the reflog browser lists
+const reflogLimit = 500
+
+// showReflog opens a window listing the previous positions of HEAD, with the
+// selected commit and buttons to reset to it or check it out
+func (app *GleamApp) showReflog() {
+	var entries []git.ReflogEntry
+	app.tasks.Go(func(ctx context.Context) error {
+		defer app.logTiming("Reflog")()
+		var err error
+		entries, err = app.git.WithContext(ctx).Reflog(reflogLimit)
+		return err
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+			return
+		}
+		app.openReflogWindow(entries)
+	})
+}
+
+func (app *GleamApp) openReflogWindow(entries []git.ReflogEntry) {
+	window := app.fyneApp.NewWindow("Reflog")
+	diffContainer := container.NewStack(NewDiffView(""))
+	selected := -1
+
+	entryList := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := entries[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(entry.Action)
+			row.Objects[1].(*widget.Label).SetText(reflogEntryLabel(entry))
+		},
+	)
+	entryList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		hash := entries[id].Hash
+		task.Latest(app.tasks, windowTask(window, "reflog-commit"), func(ctx context.Context) (string, error) {
+			return app.git.WithContext(ctx).ShowCommit(hash)
+		}, func(patch string, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			diffContainer.Objects[0] = newLazyDiffView(patch, false, app.tabWidth, nil)
+			diffContainer.Refresh()
+		})
+	}
+
+	reload := func() {
+		task.Latest(app.tasks, windowTask(window, "reflog"), func(ctx context.Context) ([]git.ReflogEntry, error) {
+			return app.git.WithContext(ctx).Reflog(reflogLimit)
+		}, func(reloaded []git.ReflogEntry, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			entries = reloaded
+			selected = -1
+			entryList.UnselectAll()
+			entryList.Refresh()
+		})
+	}
+
+	resetButton := widget.NewButton("Reset to here…", func() {
+		if selected >= 0 {
+			app.showResetDialog(window, entries[selected].Hash, reload)
+		}
+	})
+	checkoutButton := widget.NewButton("Check out", func() {
+		if selected < 0 {
+			return
+		}
+		entry := entries[selected]
+		message := fmt.Sprintf("Check out %s (%s)? HEAD will be detached from the current branch.", shortHash(entry.Hash), entry.Selector)
+		dialog.ShowConfirm("Check out", message, func(confirmed bool) {
+			if confirmed {
+				app.runUndoable(window, "checking out "+entry.Selector, git.SnapshotOptions{WorkTree: true}, func() error {
+					return app.git.Checkout(entry.Hash)
+				}, reload)
+			}
+		}, window)
+	})
+
+	split := container.NewHSplit(entryList, diffContainer)
+	split.Offset = 0.35
+	window.SetContent(container.NewBorder(nil, container.NewHBox(resetButton, checkoutButton), nil, nil, split))
+	window.Resize(fyne.NewSize(1100, 650))
+	window.Show()
+}
+
+func reflogEntryLabel(entry git.ReflogEntry) string {
+	return fmt.Sprintf("%s  %s  %s", entry.Selector, shortHash(entry.Hash), entry.Date.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,382 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+const (
+	pullModeMerge           = "Merge"
+	pullModeRebase          = "Rebase"
+	pullModeFastForwardOnly = "Fast-forward only"
+)
+
+func (app *GleamApp) fetch(opts git.FetchOptions) {
+	progress := dialog.NewProgress("Fetching", "Fetching changes from remote...", app.ui.window)
+	progress.Show()
+	app.tasks.Go(func(ctx context.Context) error {
+		return app.forgetRejectedCredentials(app.git.WithContext(ctx).Fetch(opts))
+	}, func(err error) {
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+		}
+	})
+}
+
+// scheduleAutoFetch fetches in the background every minutes, replacing the
+// previous schedule. Zero or less turns auto-fetch off
+func (app *GleamApp) scheduleAutoFetch(minutes int) {
+	if minutes == app.autoFetch.minutes {
+		return
+	}
+	if app.autoFetch.stop != nil {
+		close(app.autoFetch.stop)
+		app.autoFetch.stop = nil
+	}
+	app.autoFetch.minutes = minutes
+	if minutes <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	app.autoFetch.stop = stop
+	go func() {
+		ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				app.autoFetchNow()
+			}
+		}
+	}()
+}
+
+// autoFetchNow fetches without showing progress. Failures are only logged
+// since nobody asked for this fetch
+func (app *GleamApp) autoFetchNow() {
+	app.tasks.Go(func(ctx context.Context) error {
+		defer app.logTiming("Auto-fetch")()
+		return app.forgetRejectedCredentials(app.git.WithContext(ctx).Fetch(git.FetchOptions{}))
+	}, func(err error) {
+		if err != nil {
+			log.Printf("Auto-fetch failed: %v", err)
+		}
+	})
+}
+
+// confirmPull pulls from the upstream branch, asking first if the settings
+// say so
+func (app *GleamApp) confirmPull() {
+	app.confirmIf(app.currentSettings().ConfirmPull, "Pull", "Pull changes from the upstream branch?", func() {
+		app.pull(git.PullOptions{})
+	})
+}
+
+// confirmPush pushes the current branch, asking first if the settings say so
+func (app *GleamApp) confirmPush() {
+	app.confirmIf(app.currentSettings().ConfirmPush, "Push", "Push the current branch?", func() {
+		app.push(git.PushOptions{})
+	})
+}
+
+func (app *GleamApp) pull(opts git.PullOptions) {
+	progress := dialog.NewProgress("Pulling", "Pulling changes from remote...", app.ui.window)
+	progress.Show()
+	app.tasks.Serial(func(ctx context.Context) error {
+		if err := app.recordUndo("the pull", git.SnapshotOptions{WorkTree: true}); err != nil {
+			return err
+		}
+		return app.forgetRejectedCredentials(app.git.WithContext(ctx).Pull(opts))
+	}, func(err error) {
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+		} else {
+			app.refreshFileList()
+		}
+	})
+}
+
+func (app *GleamApp) push(opts git.PushOptions) {
+	progress := dialog.NewProgress("Pushing", "Pushing changes to remote...", app.ui.window)
+	progress.Show()
+	app.tasks.Go(func(ctx context.Context) error {
+		return app.forgetRejectedCredentials(app.git.WithContext(ctx).Push(opts))
+	}, func(err error) {
+		progress.Hide()
+		switch {
+		case errors.Is(err, git.ErrNoUpstream):
+			app.confirmSetUpstream(opts)
+		case err != nil:
+			dialog.ShowError(err, app.ui.window)
+		case app.currentSettings().NotifySuccess:
+			dialog.ShowInformation("Success", "Changes pushed successfully", app.ui.window)
+		}
+	})
+}
+
+// confirmSetUpstream offers to publish the current branch when a push failed
+// because the branch has no upstream yet
+func (app *GleamApp) confirmSetUpstream(opts git.PushOptions) {
+	branch, err := app.backend.CurrentBranch()
+	if err != nil {
+		dialog.ShowError(err, app.ui.window)
+		return
+	}
+	remote, err := app.git.DefaultRemote()
+	if err != nil {
+		dialog.ShowError(err, app.ui.window)
+		return
+	}
+
+	message := fmt.Sprintf("The branch %q has no upstream branch.\nPush it to %s/%s and set it as upstream?", branch, remote, branch)
+	dialog.ShowConfirm("No upstream branch", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		opts.Remote = remote
+		opts.Branch = branch
+		opts.SetUpstream = true
+		app.push(opts)
+	}, app.ui.window)
+}
+
+func (app *GleamApp) remoteNames() []string {
+	remotes, err := app.git.GetRemotes()
+	if err != nil {
+		log.Printf("Error listing remotes: %v", err)
+		return nil
+	}
+
+	names := make([]string, 0, len(remotes))
+	for _, remote := range remotes {
+		names = append(names, remote.Name)
+	}
+	return names
+}
+
+func (app *GleamApp) showFetchOptions() {
+	remoteSelect := widget.NewSelect(app.remoteNames(), nil)
+	remoteSelect.PlaceHolder = "(default)"
+	allCheck := widget.NewCheck("", func(checked bool) {
+		if checked {
+			remoteSelect.Disable()
+		} else {
+			remoteSelect.Enable()
+		}
+	})
+	pruneCheck := widget.NewCheck("", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Remote", remoteSelect),
+		widget.NewFormItem("All remotes", allCheck),
+		widget.NewFormItem("Prune", pruneCheck),
+	}
+	dialog.ShowForm("Fetch", "Fetch", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		app.fetch(git.FetchOptions{
+			Remote: remoteSelect.Selected,
+			All:    allCheck.Checked,
+			Prune:  pruneCheck.Checked,
+		})
+	}, app.ui.window)
+}
+
+func (app *GleamApp) showPullOptions() {
+	remoteSelect := widget.NewSelect(app.remoteNames(), nil)
+	remoteSelect.PlaceHolder = "(upstream)"
+	branchEntry := widget.NewEntry()
+	branchEntry.SetPlaceHolder("(upstream)")
+	modeRadio := widget.NewRadioGroup([]string{pullModeMerge, pullModeRebase, pullModeFastForwardOnly}, nil)
+	modeRadio.Required = true
+	modeRadio.SetSelected(pullModeMerge)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Remote", remoteSelect),
+		widget.NewFormItem("Branch", branchEntry),
+		widget.NewFormItem("Integrate by", modeRadio),
+	}
+	dialog.ShowForm("Pull", "Pull", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		app.pull(git.PullOptions{
+			Remote:          remoteSelect.Selected,
+			Branch:          branchEntry.Text,
+			Rebase:          modeRadio.Selected == pullModeRebase,
+			FastForwardOnly: modeRadio.Selected == pullModeFastForwardOnly,
+		})
+	}, app.ui.window)
+}
+
+func (app *GleamApp) showPushOptions() {
+	remoteSelect := widget.NewSelect(app.remoteNames(), nil)
+	remoteSelect.PlaceHolder = "(upstream)"
+	branchEntry := widget.NewEntry()
+	if branch, err := app.backend.CurrentBranch(); err == nil {
+		branchEntry.SetPlaceHolder(branch)
+	}
+	upstreamCheck := widget.NewCheck("", nil)
+	upstreamCheck.SetChecked(!app.git.HasUpstream())
+	forceCheck := widget.NewCheck("", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Remote", remoteSelect),
+		widget.NewFormItem("Branch", branchEntry),
+		widget.NewFormItem("Set upstream", upstreamCheck),
+		widget.NewFormItem("Force with lease", forceCheck),
+	}
+	dialog.ShowForm("Push", "Push", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		app.push(git.PushOptions{
+			Remote:         remoteSelect.Selected,
+			Branch:         branchEntry.Text,
+			SetUpstream:    upstreamCheck.Checked,
+			ForceWithLease: forceCheck.Checked,
+		})
+	}, app.ui.window)
+}
+
+func (app *GleamApp) showRemotesDialog() {
+	var remotes []git.Remote
+	selected := -1
+
+	list := widget.NewList(
+		func() int { return len(remotes) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(remotes[id].Name)
+			url := remotes[id].FetchURL
+			if remotes[id].PushURL != "" && remotes[id].PushURL != url {
+				url += " (push: " + remotes[id].PushURL + ")"
+			}
+			row.Objects[1].(*widget.Label).SetText(url)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.OnUnselected = func(widget.ListItemID) { selected = -1 }
+
+	reload := func() {
+		var err error
+		remotes, err = app.git.GetRemotes()
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+		}
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+	}
+	apply := func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+		}
+		reload()
+	}
+
+	addButton := widget.NewButton("Add", func() {
+		nameEntry := widget.NewEntry()
+		urlEntry := widget.NewEntry()
+		items := []*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("URL", urlEntry),
+		}
+		dialog.ShowForm("Add remote", "Add", "Cancel", items, func(ok bool) {
+			if ok {
+				apply(app.git.AddRemote(nameEntry.Text, urlEntry.Text))
+			}
+		}, app.ui.window)
+	})
+
+	renameButton := widget.NewButton("Rename", func() {
+		if selected < 0 {
+			return
+		}
+		remote := remotes[selected]
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(remote.Name)
+		items := []*widget.FormItem{widget.NewFormItem("New name", nameEntry)}
+		dialog.ShowForm("Rename remote", "Rename", "Cancel", items, func(ok bool) {
+			if ok && nameEntry.Text != remote.Name {
+				apply(app.git.RenameRemote(remote.Name, nameEntry.Text))
+			}
+		}, app.ui.window)
+	})
+
+	setURLButton := widget.NewButton("Set URL", func() {
+		if selected < 0 {
+			return
+		}
+		remote := remotes[selected]
+		urlEntry := widget.NewEntry()
+		urlEntry.SetText(remote.FetchURL)
+		pushCheck := widget.NewCheck("", nil)
+		items := []*widget.FormItem{
+			widget.NewFormItem("URL", urlEntry),
+			widget.NewFormItem("Push URL only", pushCheck),
+		}
+		dialog.ShowForm("Set remote URL", "Save", "Cancel", items, func(ok bool) {
+			if ok {
+				apply(app.git.SetRemoteURL(remote.Name, urlEntry.Text, pushCheck.Checked))
+			}
+		}, app.ui.window)
+	})
+
+	removeButton := widget.NewButton("Remove", func() {
+		if selected < 0 {
+			return
+		}
+		remote := remotes[selected]
+		message := fmt.Sprintf("Remove the remote %q and its remote-tracking branches?", remote.Name)
+		dialog.ShowConfirm("Remove remote", message, func(ok bool) {
+			if ok {
+				apply(app.git.RemoveRemote(remote.Name))
+			}
+		}, app.ui.window)
+	})
+	removeButton.Importance = widget.DangerImportance
+
+	reload()
+	buttons := container.NewHBox(addButton, renameButton, setURLButton, removeButton)
+	content := container.NewBorder(nil, buttons, nil, nil, list)
+
+	remotesDialog := dialog.NewCustom("Remotes", "Close", content, app.ui.window)
+	remotesDialog.Resize(fyne.NewSize(600, 300))
+	remotesDialog.Show()
+}
+
+func (app *GleamApp) showRemoteMenu(pos fyne.Position) {
+	menu := fyne.NewMenu("Remote",
+		fyne.NewMenuItem("Fetch...", app.showFetchOptions),
+		fyne.NewMenuItem("Pull...", app.showPullOptions),
+		fyne.NewMenuItem("Push...", app.showPushOptions),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Manage remotes...", app.showRemotesDialog),
+		fyne.NewMenuItem("Forget saved credentials", app.forgetCredentials),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Reflog...", app.showReflog),
+		fyne.NewMenuItem("Submodules...", app.showSubmodules),
+		fyne.NewMenuItem("Worktrees...", app.showWorktrees),
+	)
+	widget.ShowPopUpMenuAtPosition(menu, app.ui.window.Canvas(), pos)
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// lossListLimit is how many commits or files a confirmation lists by name
+const lossListLimit = 10
+
+var resetModeLabels = map[git.ResetMode]string{
+	git.ResetSoft:  "Soft: keep the index and the working tree",
+	git.ResetMixed: "Mixed: keep the working tree, unstage all changes",
+	git.ResetKeep:  "Keep: update the working tree, keep local changes",
+	git.ResetHard:  "Hard: discard all local changes",
+}
+
+// showResetDialog asks how to reset the current branch to commit, listing
+// the commits and uncommitted changes that the chosen mode loses. done is
+// called after a successful reset
+func (app *GleamApp) showResetDialog(parent fyne.Window, commit string, done func()) {
+	var branch string
+	var losses map[git.ResetMode]git.ResetLoss
+	app.tasks.Go(func(ctx context.Context) error {
+		command := app.git.WithContext(ctx)
+		var err error
+		if branch, err = command.CurrentBranch(); err != nil {
+			return err
+		}
+		losses = make(map[git.ResetMode]git.ResetLoss)
+		for _, mode := range []git.ResetMode{git.ResetMixed, git.ResetHard} {
+			if losses[mode], err = command.PreviewReset(commit, mode); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		app.confirmReset(parent, branch, commit, losses, done)
+	})
+}
+
+func (app *GleamApp) confirmReset(parent fyne.Window, branch, commit string, losses map[git.ResetMode]git.ResetLoss, done func()) {
+	labels := make([]string, len(git.ResetModes))
+	for i, mode := range git.ResetModes {
+		labels[i] = resetModeLabels[mode]
+	}
+	details := widget.NewLabel("")
+	details.Wrapping = fyne.TextWrapWord
+
+	selectedMode := func(label string) git.ResetMode {
+		return git.ResetModes[slices.Index(labels, label)]
+	}
+	modes := widget.NewRadioGroup(labels, func(label string) {
+		loss := losses[git.ResetMixed]
+		if selectedMode(label) == git.ResetHard {
+			loss = losses[git.ResetHard]
+		}
+		details.SetText(describeResetLoss(branch, loss))
+	})
+	modes.Required = true
+	modes.SetSelected(resetModeLabels[git.ResetMixed])
+
+	title := fmt.Sprintf("Reset %s to %s", branch, shortHash(commit))
+	content := container.NewVBox(modes, widget.NewSeparator(), details)
+	confirm := dialog.NewCustomConfirm(title, "Reset", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		mode := selectedMode(modes.Selected)
+		opts := git.SnapshotOptions{WorkTree: mode.UpdatesWorkTree()}
+		if mode == git.ResetHard {
+			for _, file := range losses[git.ResetHard].Files {
+				opts.Files = append(opts.Files, file.Path)
+			}
+		}
+		description := fmt.Sprintf("the %s reset to %s", mode, shortHash(commit))
+		app.runUndoable(parent, description, opts, func() error {
+			return app.git.ResetTo(commit, mode)
+		}, done)
+	}, parent)
+	confirm.Resize(fyne.NewSize(560, 420))
+	confirm.Show()
+}
+
+// describeResetLoss lists the commits that leave branch and the files whose
+// uncommitted changes are discarded
+func describeResetLoss(branch string, loss git.ResetLoss) string {
+	var text strings.Builder
+	if len(loss.Commits) == 0 {
+		fmt.Fprintf(&text, "No commits leave %s.\n", branch)
+	} else {
+		fmt.Fprintf(&text, "%d commits leave %s:\n", len(loss.Commits), branch)
+		for _, commit := range loss.Commits[:min(len(loss.Commits), lossListLimit)] {
+			fmt.Fprintf(&text, "  %s %s\n", shortHash(commit.Hash), commit.Subject)
+		}
+		if len(loss.Commits) > lossListLimit {
+			fmt.Fprintf(&text, "  and %d more\n", len(loss.Commits)-lossListLimit)
+		}
+	}
+
+	if len(loss.Files) > 0 {
+		fmt.Fprintf(&text, "\nUncommitted changes to %d files are lost:\n", len(loss.Files))
+		for _, file := range loss.Files[:min(len(loss.Files), lossListLimit)] {
+			fmt.Fprintf(&text, "  %s\n", describeFileChange(file))
+		}
+		if len(loss.Files) > lossListLimit {
+			fmt.Fprintf(&text, "  and %d more\n", len(loss.Files)-lossListLimit)
+		}
+	}
+
+	text.WriteString("\nYou can undo the reset afterwards.")
+	return text.String()
+}
+
+func describeFileChange(change git.FileChange) string {
+	if change.Binary {
+		return change.Path + " (binary)"
+	}
+	return fmt.Sprintf("%s (+%d −%d)", change.Path, change.Additions, change.Deletions)
+}
+
+// showRestoreFileDialog asks to replace file in the working tree with its
+// version at commit, where it was named source, naming the uncommitted
+// changes that are lost
+func (app *GleamApp) showRestoreFileDialog(parent fyne.Window, commit, source, file string) {
+	var local []git.FileChange
+	app.tasks.Go(func(ctx context.Context) error {
+		changes, err := app.git.WithContext(ctx).ChangedFiles(git.Comparison{From: "HEAD"})
+		local = slices.DeleteFunc(changes, func(change git.FileChange) bool { return change.Path != file })
+		return err
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+
+		message := fmt.Sprintf("Replace %s in the working tree with its version from %s?", file, shortHash(commit))
+		if source != file {
+			message = fmt.Sprintf("Replace %s in the working tree with its version from %s, when it was named %s?", file, shortHash(commit), source)
+		}
+		if len(local) > 0 {
+			message += fmt.Sprintf("\n\nUncommitted changes are lost: %s.", describeFileChange(local[0]))
+		}
+		message += "\n\nYou can undo this afterwards."
+		dialog.ShowConfirm("Restore file", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			description := fmt.Sprintf("restoring %s from %s", file, shortHash(commit))
+			app.runUndoable(parent, description, git.SnapshotOptions{Files: []string{file}}, func() error {
+				return app.git.RestoreFile(commit, source, file)
+			}, nil)
+		}, parent)
+	})
+}
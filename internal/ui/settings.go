@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"encoding/json"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"gleam/internal/git"
+)
+
+// settingsPreferenceKey stores the global defaults. Overrides of a single
+// repository are stored under the key followed by ":" and its path
+const settingsPreferenceKey = "settings"
+
+// Setting keys, matching the JSON names of the Settings fields
+const (
+	settingConfirmPush      = "confirmPush"
+	settingConfirmPull      = "confirmPull"
+	settingNotifySuccess    = "notifySuccess"
+	settingDefaultViewMode  = "defaultViewMode"
+	settingTabWidth         = "tabWidth"
+	settingSummaryLimit     = "summaryLimit"
+	settingSignOff          = "signOff"
+	settingGitPath          = "gitPath"
+	settingBackend          = "backend"
+	settingAutoFetchMinutes = "autoFetchMinutes"
+	settingMonospaceFont    = "monospaceFont"
+	settingTextSize         = "textSize"
+)
+
+const defaultTabWidth = 4
+
+// Settings are the user's preferences. The appearance settings are global,
+// everything else can be overridden per repository
+type Settings struct {
+	// General
+	ConfirmPush   bool `json:"confirmPush"`
+	ConfirmPull   bool `json:"confirmPull"`
+	NotifySuccess bool `json:"notifySuccess"`
+
+	// Diff
+	DefaultViewMode string `json:"defaultViewMode"`
+	TabWidth        int    `json:"tabWidth"`
+
+	// Commit
+	SummaryLimit int  `json:"summaryLimit"`
+	SignOff      bool `json:"signOff"`
+
+	// Git
+	GitPath          string `json:"gitPath"`
+	Backend          string `json:"backend"`
+	AutoFetchMinutes int    `json:"autoFetchMinutes"`
+
+	// Appearance
+	MonospaceFont string  `json:"monospaceFont"`
+	TextSize      float32 `json:"textSize"`
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		NotifySuccess:   true,
+		DefaultViewMode: viewModeDiff,
+		TabWidth:        defaultTabWidth,
+		SummaryLimit:    git.DefaultSummaryLimit,
+		Backend:         git.BackendExec,
+	}
+}
+
+// settingsLayer holds the values one scope sets explicitly, so a repository
+// only overrides what was changed for it
+type settingsLayer map[string]json.RawMessage
+
+func settingsKey(repo string) string {
+	if repo == "" {
+		return settingsPreferenceKey
+	}
+	return settingsPreferenceKey + ":" + repo
+}
+
+// loadSettingsLayer reads the global layer, or the layer of repo if it is set
+func loadSettingsLayer(prefs fyne.Preferences, repo string) settingsLayer {
+	layer := make(settingsLayer)
+	saved := prefs.String(settingsKey(repo))
+	if saved == "" {
+		return layer
+	}
+	if err := json.Unmarshal([]byte(saved), &layer); err != nil {
+		log.Printf("Error loading settings: %v", err)
+	}
+	return layer
+}
+
+func saveSettingsLayer(prefs fyne.Preferences, repo string, layer settingsLayer) {
+	data, err := json.Marshal(layer)
+	if err != nil {
+		log.Printf("Error saving settings: %v", err)
+		return
+	}
+	prefs.SetString(settingsKey(repo), string(data))
+}
+
+// mergeSettings applies layers on top of base in order
+func mergeSettings(base Settings, layers ...settingsLayer) Settings {
+	for _, layer := range layers {
+		data, err := json.Marshal(layer)
+		if err != nil {
+			continue
+		}
+		if err := json.Unmarshal(data, &base); err != nil {
+			log.Printf("Error applying settings: %v", err)
+		}
+	}
+	return base
+}
+
+// loadSettings computes the effective settings of the current repository
+func (app *GleamApp) loadSettings() {
+	prefs := app.fyneApp.Preferences()
+	settings := mergeSettings(defaultSettings(), loadSettingsLayer(prefs, ""), loadSettingsLayer(prefs, app.git.WorkingDir))
+
+	app.mutex.Lock()
+	app.state.settings = settings
+	app.mutex.Unlock()
+}
+
+func (app *GleamApp) currentSettings() Settings {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	return app.state.settings
+}
+
+// setSetting stores value for key in the global defaults, or as an override
+// for the current repository if repo is set, and applies the result
+func (app *GleamApp) setSetting(repo bool, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Error saving setting %s: %v", key, err)
+		return
+	}
+
+	scope := ""
+	if repo {
+		scope = app.git.WorkingDir
+	}
+	prefs := app.fyneApp.Preferences()
+	layer := loadSettingsLayer(prefs, scope)
+	layer[key] = data
+	saveSettingsLayer(prefs, scope, layer)
+
+	app.loadSettings()
+	app.applySettings()
+}
+
+// resetRepositorySettings drops the overrides of the current repository
+func (app *GleamApp) resetRepositorySettings() {
+	app.fyneApp.Preferences().RemoveValue(settingsKey(app.git.WorkingDir))
+	app.loadSettings()
+	app.applySettings()
+}
+
+// applySettings makes the effective settings take effect. The git binary is
+// only read at startup, and the theme is only reapplied when a setting that
+// affects drawing changed, since that refreshes every window
+func (app *GleamApp) applySettings() {
+	settings := app.currentSettings()
+	previous := app.appliedSettings
+	app.appliedSettings = &settings
+
+	app.scheduleAutoFetch(settings.AutoFetchMinutes)
+
+	if previous != nil && previous.TabWidth == settings.TabWidth &&
+		previous.MonospaceFont == settings.MonospaceFont && previous.TextSize == settings.TextSize {
+		return
+	}
+	app.applyTheme()
+}
+
+// tabWidth is the number of columns a tab advances to in the diff and blame
+// views of the repository
+func (app *GleamApp) tabWidth() int {
+	if width := app.currentSettings().TabWidth; width > 0 {
+		return width
+	}
+	return defaultTabWidth
+}
+
+// confirmIf runs action, asking first if enabled is set
+func (app *GleamApp) confirmIf(enabled bool, title, message string, action func()) {
+	if !enabled {
+		action()
+		return
+	}
+	dialog.ShowConfirm(title, message, func(confirmed bool) {
+		if confirmed {
+			action()
+		}
+	}, app.ui.window)
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// shortcutEntry is an entry that hands key bindings to the keymap. A plain
+// entry swallows every shortcut while it has focus
+type shortcutEntry struct {
+	widget.Entry
+	keymap *keymap
+}
+
+func newShortcutEntry(keymap *keymap, multiLine bool) *shortcutEntry {
+	entry := &shortcutEntry{keymap: keymap}
+	entry.MultiLine = multiLine
+	if multiLine {
+		entry.Wrapping = fyne.TextWrapWord
+	}
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+func (e *shortcutEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if e.keymap != nil && e.keymap.typedShortcut(shortcut) {
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
+
+func (e *shortcutEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.keymap != nil && e.keymap.typedFunctionKey(key) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+func (e *shortcutEntry) KeyDown(key *fyne.KeyEvent) {
+	if e.keymap != nil {
+		e.keymap.trackShift(key, true)
+	}
+	e.Entry.KeyDown(key)
+}
+
+func (e *shortcutEntry) KeyUp(key *fyne.KeyEvent) {
+	if e.keymap != nil {
+		e.keymap.trackShift(key, false)
+	}
+	e.Entry.KeyUp(key)
+}
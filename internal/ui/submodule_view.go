@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// submodule returns the submodule at path, if path is one
+func (app *GleamApp) submodule(path string) (git.Submodule, bool) {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	index := slices.IndexFunc(app.state.submodules, func(s git.Submodule) bool { return s.Path == path })
+	if index < 0 {
+		return git.Submodule{}, false
+	}
+	return app.state.submodules[index], true
+}
+
+// submoduleState summarizes the recorded and checked-out commits of s
+func submoduleState(s git.Submodule) string {
+	var parts []string
+	switch {
+	case !s.Initialized():
+		parts = append(parts, "not initialized, records "+shortHash(s.Recorded))
+	case s.Moved():
+		parts = append(parts, fmt.Sprintf("records %s, checked out %s", shortHash(s.Recorded), shortHash(s.Current)))
+	default:
+		parts = append(parts, "at "+shortHash(s.Current))
+	}
+	if s.Dirty {
+		parts = append(parts, "modified")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// submoduleDiffView shows how the commit of a submodule changed, with the
+// commits between the old and the new one
+func submoduleDiffView(change git.SubmoduleChange) fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("Submodule "+change.Path, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	rows := []fyne.CanvasObject{title}
+
+	switch {
+	case change.Old == "":
+		rows = append(rows, widget.NewLabel("New submodule at "+shortHash(change.New)))
+	case change.Old == change.New:
+		rows = append(rows, widget.NewLabel("Commit unchanged at "+shortHash(change.New)))
+	default:
+		rows = append(rows, widget.NewLabel(fmt.Sprintf("Commit changed from %s to %s", shortHash(change.Old), shortHash(change.New))))
+	}
+	if change.Dirty {
+		rows = append(rows, widget.NewLabel("The submodule has uncommitted changes"))
+	}
+	if !change.Initialized() {
+		rows = append(rows, widget.NewLabel("The submodule is not initialized"))
+	}
+
+	commitLines := func(prefix string, entries []git.LogEntry) {
+		for _, entry := range entries {
+			rows = append(rows, widget.NewLabelWithStyle(
+				fmt.Sprintf("%s %s %s", prefix, shortHash(entry.Hash), entry.Subject),
+				fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}))
+		}
+	}
+	commitLines(">", change.Added)
+	commitLines("<", change.Removed)
+	if change.Old != "" && change.Old != change.New && change.Initialized() && len(change.Added)+len(change.Removed) == 0 {
+		rows = append(rows, widget.NewLabel("The commits between them are not in the submodule, fetch it to list them"))
+	}
+
+	return container.NewVScroll(container.NewVBox(rows...))
+}
+
+// openSubmodule opens the submodule at path in its own tab
+func (app *GleamApp) openSubmodule(path string) {
+	if app.openRepository == nil {
+		return
+	}
+	app.openRepository(filepath.Join(app.git.WorkingDir, path))
+}
+
+// runSubmoduleOperation runs a submodule command in the background and
+// reloads the submodules afterwards. operation is given a command that is
+// killed when the repository is closed
+func (app *GleamApp) runSubmoduleOperation(window fyne.Window, title string, operation func(command *git.GitCommand) error, done func()) {
+	progress := dialog.NewProgress(title, title+" submodules...", window)
+	progress.Show()
+	app.tasks.Serial(func(ctx context.Context) error {
+		return app.forgetRejectedCredentials(operation(app.git.WithContext(ctx)))
+	}, func(err error) {
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(err, window)
+		}
+		app.refreshFileList()
+		app.refreshDiffView()
+		if done != nil {
+			done()
+		}
+	})
+}
+
+// showSubmodules opens a window listing the submodules with their state, to
+// initialize, update, sync or open them
+func (app *GleamApp) showSubmodules() {
+	submodules, err := app.git.Submodules()
+	if err != nil {
+		dialog.ShowError(err, app.ui.window)
+		return
+	}
+
+	window := app.fyneApp.NewWindow("Submodules")
+	selected := -1
+
+	list := widget.NewList(
+		func() int { return len(submodules) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			s := submodules[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(s.Path)
+			row.Objects[1].(*widget.Label).SetText(submoduleState(s) + "  " + s.URL)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.OnUnselected = func(widget.ListItemID) { selected = -1 }
+
+	reload := func() {
+		reloaded, err := app.git.Submodules()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		submodules = reloaded
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	// selectedPaths is the selected submodule, or nil for all of them
+	selectedPaths := func() []string {
+		if selected < 0 || selected >= len(submodules) {
+			return nil
+		}
+		return []string{submodules[selected].Path}
+	}
+	operation := func(title string, run func(*git.GitCommand, []string) error) *widget.Button {
+		return widget.NewButton(title, func() {
+			paths := selectedPaths()
+			app.runSubmoduleOperation(window, title, func(command *git.GitCommand) error { return run(command, paths) }, reload)
+		})
+	}
+
+	openButton := widget.NewButton("Open", func() {
+		if paths := selectedPaths(); len(paths) > 0 {
+			app.openSubmodule(paths[0])
+		}
+	})
+	if app.openRepository == nil {
+		openButton.Disable()
+	}
+
+	hint := widget.NewLabel("Init, Update and Sync apply to the selected submodule, or to all of them if none is selected.")
+	hint.Wrapping = fyne.TextWrapWord
+	buttons := container.NewHBox(
+		operation("Init", (*git.GitCommand).InitSubmodules),
+		operation("Update", (*git.GitCommand).UpdateSubmodules),
+		operation("Sync", (*git.GitCommand).SyncSubmodules),
+		openButton,
+	)
+
+	window.SetContent(container.NewBorder(nil, container.NewVBox(hint, buttons), nil, nil, list))
+	window.Resize(fyne.NewSize(700, 450))
+	window.Show()
+}
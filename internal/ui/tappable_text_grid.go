@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// TappableTextGrid is a TextGrid that reports which row was tapped
+type TappableTextGrid struct {
+	widget.TextGrid
+	OnTappedRow          func(row int, event *fyne.PointEvent)
+	OnTappedSecondaryRow func(row int, event *fyne.PointEvent)
+}
+
+func NewTappableTextGrid() *TappableTextGrid {
+	grid := &TappableTextGrid{}
+	grid.ExtendBaseWidget(grid)
+	return grid
+}
+
+func (g *TappableTextGrid) Tapped(event *fyne.PointEvent) {
+	if g.OnTappedRow != nil {
+		g.OnTappedRow(g.rowAt(event.Position), event)
+	}
+}
+
+func (g *TappableTextGrid) TappedSecondary(event *fyne.PointEvent) {
+	if g.OnTappedSecondaryRow != nil {
+		g.OnTappedSecondaryRow(g.rowAt(event.Position), event)
+	}
+}
+
+// rowAt maps a position inside the grid to a row, measuring cells the same
+// way the TextGrid renderer does
+func (g *TappableTextGrid) rowAt(pos fyne.Position) int {
+	size := fyne.MeasureText("M", g.Theme().Size(theme.SizeNameText), fyne.TextStyle{Monospace: true})
+	cellHeight := math.Round(float64(size.Height))
+	if cellHeight <= 0 {
+		return 0
+	}
+
+	row := int(float64(pos.Y) / cellHeight)
+	if row >= len(g.Rows) {
+		row = len(g.Rows) - 1
+	}
+	return max(row, 0)
+}
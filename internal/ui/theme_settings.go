@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"image/color"
+	"log"
+	"slices"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+
+	"gleam/internal/themes"
+)
+
+const (
+	appearanceSystem = "System"
+	appearanceLight  = "Light"
+	appearanceDark   = "Dark"
+
+	themePreferenceKey       = "theme.name"
+	appearancePreferenceKey  = "theme.appearance"
+	syntaxLightPreferenceKey = "theme.syntaxLight"
+	syntaxDarkPreferenceKey  = "theme.syntaxDark"
+
+	themeDefaultSyntax = "(theme default)"
+)
+
+// diffTheme is the palette and syntax style that diff and blame views are
+// drawn with
+type diffTheme struct {
+	palette themes.Palette
+	style   *chroma.Style
+}
+
+var activeDiffTheme atomic.Pointer[diffTheme]
+
+// currentDiffTheme returns the theme chosen by the user, or the dark variant
+// of the default theme before the preferences are loaded
+func currentDiffTheme() *diffTheme {
+	if current := activeDiffTheme.Load(); current != nil {
+		return current
+	}
+	activeDiffTheme.CompareAndSwap(nil, newDiffTheme(themes.Default().Palette(true), ""))
+	return activeDiffTheme.Load()
+}
+
+func newDiffTheme(palette themes.Palette, syntax string) *diffTheme {
+	if syntax != "" {
+		palette.Syntax = syntax
+	}
+	return &diffTheme{palette: palette, style: styles.Get(palette.Syntax)}
+}
+
+// appTheme is the default Fyne theme adjusted by the settings. It can force
+// one variant regardless of the system setting and replace the text size
+// and the monospace font
+type appTheme struct {
+	fyne.Theme
+	variant   fyne.ThemeVariant
+	forced    bool
+	textSize  float32
+	monospace fyne.Resource
+}
+
+func (t appTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if t.forced {
+		variant = t.variant
+	}
+	return t.Theme.Color(name, variant)
+}
+
+func (t appTheme) Size(name fyne.ThemeSizeName) float32 {
+	if name == theme.SizeNameText && t.textSize > 0 {
+		return t.textSize
+	}
+	return t.Theme.Size(name)
+}
+
+func (t appTheme) Font(style fyne.TextStyle) fyne.Resource {
+	if style.Monospace && t.monospace != nil {
+		return t.monospace
+	}
+	return t.Theme.Font(style)
+}
+
+// loadThemes collects the built-in themes and those in the user's theme
+// directory
+func (app *GleamApp) loadThemes() {
+	app.themes = themes.Builtin()
+
+	dir, err := themes.UserDir()
+	if err != nil {
+		log.Printf("Error locating user themes: %v", err)
+		return
+	}
+	userThemes, err := themes.Load(dir)
+	if err != nil {
+		log.Printf("Error loading user themes: %v", err)
+	}
+	app.themes = append(app.themes, userThemes...)
+}
+
+func (app *GleamApp) isDarkAppearance() bool {
+	switch app.fyneApp.Preferences().StringWithFallback(appearancePreferenceKey, appearanceSystem) {
+	case appearanceLight:
+		return false
+	case appearanceDark:
+		return true
+	}
+	return app.fyneApp.Settings().ThemeVariant() == theme.VariantDark
+}
+
+// themeChanged reports whether the saved preferences resolve to another
+// diff theme than the one in use, for example after the system switched
+// between light and dark
+func (app *GleamApp) themeChanged() bool {
+	return *app.resolveDiffTheme() != *currentDiffTheme()
+}
+
+// resolveDiffTheme builds the diff theme from the saved preferences
+func (app *GleamApp) resolveDiffTheme() *diffTheme {
+	prefs := app.fyneApp.Preferences()
+	dark := app.isDarkAppearance()
+	palette := themes.Find(app.themes, prefs.StringWithFallback(themePreferenceKey, themes.DefaultName)).Palette(dark)
+
+	syntaxKey := syntaxLightPreferenceKey
+	if dark {
+		syntaxKey = syntaxDarkPreferenceKey
+	}
+	return newDiffTheme(palette, prefs.String(syntaxKey))
+}
+
+// applyTheme activates the saved theme preferences. Setting the Fyne theme
+// refreshes every open window, which restyles the diff views in them
+func (app *GleamApp) applyTheme() {
+	activeDiffTheme.Store(app.resolveDiffTheme())
+
+	settings := app.currentSettings()
+	fyneTheme := appTheme{Theme: theme.DefaultTheme(), textSize: settings.TextSize}
+	switch app.fyneApp.Preferences().StringWithFallback(appearancePreferenceKey, appearanceSystem) {
+	case appearanceLight:
+		fyneTheme.variant, fyneTheme.forced = theme.VariantLight, true
+	case appearanceDark:
+		fyneTheme.variant, fyneTheme.forced = theme.VariantDark, true
+	}
+	if settings.MonospaceFont != "" {
+		font, err := fyne.LoadResourceFromPath(settings.MonospaceFont)
+		if err != nil {
+			log.Printf("Error loading monospace font: %v", err)
+		}
+		fyneTheme.monospace = font
+	}
+	app.fyneApp.Settings().SetTheme(fyneTheme)
+
+	app.mutex.RLock()
+	mode := app.state.viewMode
+	app.mutex.RUnlock()
+	if mode == viewModeBlame && app.ui.diffContainer != nil {
+		app.refreshDiffView()
+	}
+}
+
+// createThemeSettings lets the user choose the theme, appearance and syntax
+// styles. Changes are applied and saved right away
+func (app *GleamApp) createThemeSettings() fyne.CanvasObject {
+	prefs := app.fyneApp.Preferences()
+
+	names := make([]string, len(app.themes))
+	for i, t := range app.themes {
+		names[i] = t.Name
+	}
+	themeSelect := widget.NewSelect(names, nil)
+	themeSelect.SetSelected(themes.Find(app.themes, prefs.StringWithFallback(themePreferenceKey, themes.DefaultName)).Name)
+	themeSelect.OnChanged = func(name string) {
+		prefs.SetString(themePreferenceKey, name)
+		app.applyTheme()
+	}
+
+	appearance := widget.NewRadioGroup([]string{appearanceSystem, appearanceLight, appearanceDark}, nil)
+	appearance.Horizontal = true
+	appearance.Required = true
+	appearance.SetSelected(prefs.StringWithFallback(appearancePreferenceKey, appearanceSystem))
+	appearance.OnChanged = func(value string) {
+		prefs.SetString(appearancePreferenceKey, value)
+		app.applyTheme()
+	}
+
+	syntaxSelect := func(key string) *widget.Select {
+		options := slices.Concat([]string{themeDefaultSyntax}, styles.Names())
+		sel := widget.NewSelect(options, nil)
+		sel.SetSelected(themeDefaultSyntax)
+		if style := prefs.String(key); style != "" {
+			sel.SetSelected(style)
+		}
+		sel.OnChanged = func(style string) {
+			if style == themeDefaultSyntax {
+				style = ""
+			}
+			prefs.SetString(key, style)
+			app.applyTheme()
+		}
+		return sel
+	}
+
+	themeDir, err := themes.UserDir()
+	if err != nil {
+		themeDir = err.Error()
+	}
+	reloadButton := widget.NewButtonWithIcon("Reload", theme.ViewRefreshIcon(), func() {
+		app.loadThemes()
+		names = names[:0]
+		for _, t := range app.themes {
+			names = append(names, t.Name)
+		}
+		themeSelect.SetOptions(names)
+		app.applyTheme()
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem("Theme", container.NewBorder(nil, nil, nil, reloadButton, themeSelect)),
+		widget.NewFormItem("Appearance", appearance),
+		widget.NewFormItem("Syntax (light)", syntaxSelect(syntaxLightPreferenceKey)),
+		widget.NewFormItem("Syntax (dark)", syntaxSelect(syntaxDarkPreferenceKey)),
+	)
+	hint := widget.NewLabel("User themes are read from *.json files in " + themeDir)
+	hint.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(form, hint)
+}
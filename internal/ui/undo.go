@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"gleam/internal/git"
+)
+
+// undoLimit is how many operations of a repository can be undone
+const undoLimit = 20
+
+// undoAction is an operation that can be undone by restoring the snapshot
+// taken before it
+type undoAction struct {
+	// id tells apart operations with the same description
+	id          uint64
+	description string
+	snapshot    git.Snapshot
+}
+
+// recordUndo takes a snapshot before the operation described by description
+// runs. It is called from the work of a serial task, so the snapshot sees
+// the repository as the operation will. Operations do not run when it
+// fails, so everything they change can be undone
+func (app *GleamApp) recordUndo(description string, opts git.SnapshotOptions) error {
+	snapshot, err := app.backend.TakeSnapshot(opts)
+	if err != nil {
+		return err
+	}
+
+	app.undo.Lock()
+	defer app.undo.Unlock()
+	app.undo.next++
+	app.undo.actions = append(app.undo.actions, undoAction{id: app.undo.next, description: description, snapshot: snapshot})
+	if len(app.undo.actions) > undoLimit {
+		app.undo.actions = slices.Delete(app.undo.actions, 0, len(app.undo.actions)-undoLimit)
+	}
+	return nil
+}
+
+func (app *GleamApp) lastUndo() (undoAction, bool) {
+	app.undo.Lock()
+	defer app.undo.Unlock()
+	if len(app.undo.actions) == 0 {
+		return undoAction{}, false
+	}
+	return app.undo.actions[len(app.undo.actions)-1], true
+}
+
+// undoLast asks to restore the state before the last recorded operation,
+// listing what restoring changes compared to the repository as it is now
+func (app *GleamApp) undoLast() {
+	action, ok := app.lastUndo()
+	if !ok {
+		dialog.ShowInformation("Undo", "There is nothing to undo.", app.ui.window)
+		return
+	}
+
+	var current git.Snapshot
+	app.tasks.Go(func(ctx context.Context) error {
+		var err error
+		current, err = git.WithContext(app.backend, ctx).TakeSnapshot(git.SnapshotOptions{})
+		return err
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+			return
+		}
+		message := fmt.Sprintf("Undo %s? This restores:\n\n%s", action.description, strings.Join(describeUndo(action.snapshot, current), "\n"))
+		dialog.ShowConfirm("Undo", message, func(confirmed bool) {
+			if confirmed {
+				app.restoreUndo(action)
+			}
+		}, app.ui.window)
+	})
+}
+
+// restoreUndo restores the snapshot of action and drops it from the stack
+func (app *GleamApp) restoreUndo(action undoAction) {
+	app.tasks.Serial(func(context.Context) error {
+		if err := app.backend.RestoreSnapshot(action.snapshot); err != nil {
+			return err
+		}
+		app.undo.Lock()
+		defer app.undo.Unlock()
+		app.undo.actions = slices.DeleteFunc(app.undo.actions, func(recorded undoAction) bool {
+			return recorded.id == action.id
+		})
+		return nil
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, app.ui.window)
+		}
+		app.refreshFileList()
+		app.refreshDiffView()
+	})
+}
+
+// describeUndo lists what restoring saved changes in the repository, whose
+// current state is current
+func describeUndo(saved, current git.Snapshot) []string {
+	lines := make([]string, 0)
+	switch {
+	case saved.Branch != "" && saved.Branch != current.Branch:
+		lines = append(lines, "• HEAD back on branch "+saved.Branch)
+	case saved.Branch == "" && saved.Head != current.Head:
+		lines = append(lines, "• HEAD detached at "+shortHash(saved.Head))
+	}
+	if saved.Branch != "" && saved.Head == "" && current.Head != "" {
+		lines = append(lines, "• "+saved.Branch+" without commits")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(saved.Refs)) {
+		hash := saved.Refs[name]
+		if now := current.Refs[name]; now != hash {
+			branch := strings.TrimPrefix(name, "refs/heads/")
+			if now == "" {
+				lines = append(lines, fmt.Sprintf("• branch %s at %s", branch, shortHash(hash)))
+			} else {
+				lines = append(lines, fmt.Sprintf("• %s from %s back to %s", branch, shortHash(now), shortHash(hash)))
+			}
+		}
+	}
+
+	if saved.WorkTree && saved.Head != current.Head {
+		lines = append(lines, "• the working tree at "+shortHash(saved.Head)+", keeping local changes")
+	}
+	if saved.Index != "" && saved.Index != current.Index {
+		lines = append(lines, "• the staged changes")
+	}
+	for _, file := range slices.Sorted(maps.Keys(saved.Files)) {
+		if saved.Files[file].Blob == "" {
+			lines = append(lines, "• "+file+" removed")
+		} else {
+			lines = append(lines, "• "+file)
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "• nothing, the repository is already in that state")
+	}
+	return lines
+}
+
+// discardChanges asks to drop the staged and unstaged changes of file,
+// keeping its content so the discard can be undone
+func (app *GleamApp) discardChanges(file string) {
+	if file == "" {
+		return
+	}
+	message := fmt.Sprintf("Discard all changes to %s? You can undo this afterwards.", file)
+	dialog.ShowConfirm("Discard changes", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		app.runUndoable(app.ui.window, "discarding the changes to "+file, git.SnapshotOptions{Files: []string{file}}, func() error {
+			return app.git.Discard([]string{file})
+		}, nil)
+	}, app.ui.window)
+}
+
+// runUndoable records a snapshot with opts and runs operation, reporting
+// errors in window. done is called after the operation succeeded
+func (app *GleamApp) runUndoable(window fyne.Window, description string, opts git.SnapshotOptions, operation func() error, done func()) {
+	app.tasks.Serial(func(context.Context) error {
+		if err := app.recordUndo(description, opts); err != nil {
+			return err
+		}
+		return operation()
+	}, func(err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+		} else if done != nil {
+			done()
+		}
+		app.refreshFileList()
+		app.refreshDiffView()
+	})
+}
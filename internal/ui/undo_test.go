@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"gleam/internal/git"
+)
+
+func TestRestoreUndoDropsOnlyItsAction(t *testing.T) {
+	backend := newChangedBackend(t)
+	app := newTestGleamApp(t, backend)
+
+	// Both operations have the same description, the restored one is not
+	// the last
+	mustSucceed(t, app.recordUndo("the commit “Fix”", git.SnapshotOptions{}))
+	mustSucceed(t, backend.Stage([]string{"b.txt"}))
+	mustSucceed(t, app.recordUndo("the commit “Fix”", git.SnapshotOptions{}))
+	first := app.undo.actions[0]
+
+	app.restoreUndo(first)
+	waitForTasks(app)
+
+	if len(app.undo.actions) != 1 || app.undo.actions[0].id == first.id {
+		t.Errorf("after restoring the first action the stack is %+v", app.undo.actions)
+	}
+	if staged, _ := backend.GetStagedFiles(); len(staged) != 0 {
+		t.Errorf("restoring the first snapshot left %v staged", staged)
+	}
+}
+
+func TestConsoleCommandsThatRewriteHistoryAreUndoable(t *testing.T) {
+	app := newTestGleamApp(t, newChangedBackend(t))
+
+	for _, args := range [][]string{{"status"}, {"commit", "--amend", "-m", "Reworded"}, {"rebase", "main"}} {
+		app.runConsoleCommand(args, func(string) {})
+		waitForTasks(app)
+	}
+
+	var descriptions []string
+	for _, action := range app.undo.actions {
+		descriptions = append(descriptions, action.description)
+	}
+	want := []string{"“git commit --amend -m Reworded”", "“git rebase main”"}
+	if !slices.Equal(descriptions, want) {
+		t.Errorf("console commands recorded %q for undo, want %q", descriptions, want)
+	}
+	if !app.undo.actions[1].snapshot.WorkTree {
+		t.Errorf("undoing a rebase does not restore the working tree")
+	}
+}
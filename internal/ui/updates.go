@@ -0,0 +1,61 @@
+package ui
+
+// uiUpdates hands functions from background goroutines to one goroutine
+// that applies them in order, so those updates never race with each other.
+// Once closed, queued and later updates are dropped
+type uiUpdates struct {
+	queue  chan func()
+	closed chan struct{}
+}
+
+// newUIUpdates starts the goroutine that applies the updates
+func newUIUpdates() *uiUpdates {
+	u := &uiUpdates{
+		queue:  make(chan func(), 64),
+		closed: make(chan struct{}),
+	}
+	go u.apply()
+	return u
+}
+
+// run queues fn, or drops it if the updates are closed
+func (u *uiUpdates) run(fn func()) {
+	// A select picks any case that is ready, so without this check fn could
+	// still be queued after close
+	if u.isClosed() {
+		return
+	}
+	select {
+	case u.queue <- fn:
+	case <-u.closed:
+	}
+}
+
+func (u *uiUpdates) apply() {
+	for {
+		select {
+		case update := <-u.queue:
+			if u.isClosed() {
+				return
+			}
+			update()
+		case <-u.closed:
+			return
+		}
+	}
+}
+
+// close drops pending updates and stops applying new ones
+func (u *uiUpdates) close() {
+	close(u.closed)
+}
+
+// isClosed reports whether close was called
+func (u *uiUpdates) isClosed() bool {
+	select {
+	case <-u.closed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,312 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// Workspace is the main window. It holds one GleamApp per open repository,
+// each in its own tab
+type Workspace struct {
+	app    fyne.App
+	window fyne.Window
+
+	tabs    *container.DocTabs
+	welcome fyne.CanvasObject
+	content *fyne.Container
+	keymap  *keymap
+
+	mutex sync.RWMutex
+	repos []*GleamApp
+}
+
+func NewWorkspace() *Workspace {
+	application := app.NewWithID("com.bennowo.gleam")
+	application.SetIcon(theme.FileIcon())
+	return newWorkspace(application)
+}
+
+// newWorkspace builds the main window on application
+func newWorkspace(application fyne.App) *Workspace {
+	w := &Workspace{
+		app:    application,
+		window: application.NewWindow("Gleam"),
+	}
+	w.keymap = newKeymap(w)
+	w.tabs = container.NewDocTabs()
+	w.tabs.OnSelected = func(*container.TabItem) { w.activate() }
+	w.tabs.CloseIntercept = func(item *container.TabItem) {
+		w.closeRepository(slices.Index(w.tabs.Items, item))
+	}
+	w.welcome = w.createWelcome()
+	w.content = container.NewStack(w.welcome)
+
+	logLifecycle(application, w)
+	w.watchSystemTheme()
+	return w
+}
+
+// active returns the repository of the selected tab, or nil if none is open
+func (w *Workspace) active() *GleamApp {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	index := w.tabs.SelectedIndex()
+	if index < 0 || index >= len(w.repos) {
+		return nil
+	}
+	return w.repos[index]
+}
+
+// activate shows the repository of the selected tab. The session is saved
+// whenever the selection or the open repositories change, so it survives
+// quitting without closing the window
+func (w *Workspace) activate() {
+	defer w.saveSession()
+	repo := w.active()
+	if repo == nil {
+		w.window.SetTitle("Gleam")
+		return
+	}
+	w.window.SetTitle("Gleam — " + repo.Name())
+	repo.activate()
+}
+
+// Open opens the repository containing path in a new tab, or selects its
+// tab if it is already open
+func (w *Workspace) Open(path string) (*GleamApp, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := git.NewGitCommand(abs).TopLevel()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a git repository", abs)
+	}
+	root = filepath.Clean(root)
+
+	w.mutex.RLock()
+	index := slices.IndexFunc(w.repos, func(repo *GleamApp) bool {
+		return repo.git.WorkingDir == root
+	})
+	w.mutex.RUnlock()
+	if index >= 0 {
+		w.tabs.SelectIndex(index)
+		return w.active(), nil
+	}
+
+	repo := NewGleamApp(w.window, w.keymap, root)
+	repo.openRepository = w.openOrShowError
+	item := container.NewTabItem(repo.Name(), repo.Content())
+
+	w.mutex.Lock()
+	w.repos = append(w.repos, repo)
+	w.mutex.Unlock()
+
+	w.content.Objects = []fyne.CanvasObject{w.tabs}
+	w.content.Refresh()
+	w.tabs.Append(item)
+	w.tabs.Select(item)
+
+	touchRecentRepository(root)
+	return repo, nil
+}
+
+// openWithDialog asks for a repository folder and opens it
+func (w *Workspace) openWithDialog() {
+	dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+		if err != nil || folder == nil {
+			return
+		}
+		w.openOrShowError(folder.Path())
+	}, w.window)
+}
+
+func (w *Workspace) openOrShowError(path string) {
+	if _, err := w.Open(path); err != nil {
+		dialog.ShowError(err, w.window)
+	}
+}
+
+// closeSelected closes the repository of the selected tab
+func (w *Workspace) closeSelected() {
+	w.closeRepository(w.tabs.SelectedIndex())
+}
+
+// selectTab selects the tab delta tabs after the selected one, wrapping
+// around at either end
+func (w *Workspace) selectTab(delta int) {
+	count := len(w.tabs.Items)
+	if count == 0 {
+		return
+	}
+	w.tabs.SelectIndex(((w.tabs.SelectedIndex()+delta)%count + count) % count)
+}
+
+func (w *Workspace) closeRepository(index int) {
+	w.mutex.Lock()
+	if index < 0 || index >= len(w.repos) {
+		w.mutex.Unlock()
+		return
+	}
+	repo := w.repos[index]
+	w.repos = slices.Delete(w.repos, index, index+1)
+	w.mutex.Unlock()
+
+	// Removing the selected tab only reports a selection if the index of
+	// the selected tab changes
+	wasSelected := w.tabs.SelectedIndex() == index
+	w.tabs.RemoveIndex(index)
+	go repo.Close()
+
+	switch {
+	case len(w.tabs.Items) == 0:
+		w.content.Objects = []fyne.CanvasObject{w.welcome}
+		w.content.Refresh()
+		w.activate()
+	case wasSelected && index < len(w.tabs.Items):
+		w.activate()
+	default:
+		w.saveSession()
+	}
+}
+
+func (w *Workspace) closeAll() {
+	w.mutex.Lock()
+	repos := w.repos
+	w.repos = nil
+	w.mutex.Unlock()
+
+	for _, repo := range repos {
+		repo.Close()
+	}
+}
+
+// createWelcome is shown while no repository is open
+func (w *Workspace) createWelcome() fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("No repository open", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	openButton := widget.NewButtonWithIcon("Open repository…", theme.FolderOpenIcon(), w.openWithDialog)
+	openButton.Importance = widget.HighImportance
+	recentButton := widget.NewButtonWithIcon("Recent repositories…", theme.HistoryIcon(), w.showRecentRepositories)
+	return container.NewCenter(container.NewVBox(title, openButton, recentButton))
+}
+
+// createTopBar holds the actions that are not tied to one repository
+func (w *Workspace) createTopBar() fyne.CanvasObject {
+	openButton := widget.NewButtonWithIcon("Open…", theme.FolderOpenIcon(), w.openWithDialog)
+
+	recentButton := widget.NewButtonWithIcon("Recent", theme.HistoryIcon(), nil)
+	recentButton.OnTapped = func() {
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(recentButton)
+		w.showRecentMenu(pos.AddXY(0, recentButton.Size().Height))
+	}
+	dashboardButton := widget.NewButtonWithIcon("Repositories", theme.GridIcon(), w.showDashboard)
+	return container.NewHBox(openButton, recentButton, dashboardButton)
+}
+
+// watchSystemTheme restyles the diffs when the system switches between
+// light and dark
+func (w *Workspace) watchSystemTheme() {
+	changes := make(chan fyne.Settings)
+	w.app.Settings().AddChangeListener(changes)
+	go func() {
+		for range changes {
+			if repo := w.active(); repo != nil {
+				repo.runOnUI(func() {
+					if repo.themeChanged() {
+						repo.applyTheme()
+					}
+				})
+			}
+		}
+	}()
+}
+
+// restore reopens the repositories of the last session. Without a saved
+// session the current directory is opened if it is a repository
+func (w *Workspace) restore() {
+	saved, ok := loadSession()
+	if !ok {
+		if dir, err := os.Getwd(); err == nil {
+			if _, err := w.Open(dir); err != nil {
+				log.Printf("Not opening current directory: %v", err)
+			}
+		}
+		w.window.Resize(fyne.NewSize(1200, 600))
+		return
+	}
+
+	// Repositories that cannot be opened anymore have no tab, so the
+	// selected tab is found by its repository rather than its index
+	var active *GleamApp
+	for i, repository := range saved.Repositories {
+		repo, err := w.Open(repository.Path)
+		if err != nil {
+			log.Printf("Error restoring repository: %v", err)
+			continue
+		}
+		if repository.SelectedFile != "" {
+			repo.selectFile(repository.SelectedFile)
+		}
+		if i == saved.Active {
+			active = repo
+		}
+	}
+	w.mutex.RLock()
+	index := slices.Index(w.repos, active)
+	w.mutex.RUnlock()
+	if index >= 0 {
+		w.tabs.SelectIndex(index)
+	}
+	w.window.Resize(fyne.NewSize(saved.Width, saved.Height))
+	w.saveSession()
+}
+
+// Run shows the window and blocks until the application quits
+func (w *Workspace) Run() {
+	w.window.SetContent(container.NewBorder(w.createTopBar(), nil, nil, nil, w.content))
+	w.keymap.register(w.window.Canvas())
+	w.window.SetCloseIntercept(func() {
+		w.saveSession()
+		w.window.Close()
+	})
+
+	w.restore()
+	w.window.CenterOnScreen()
+	w.window.ShowAndRun()
+}
+
+func logLifecycle(fyneApp fyne.App, w *Workspace) {
+	lifecycle := fyneApp.Lifecycle()
+
+	lifecycle.SetOnStarted(func() {
+		log.Println("Lifecycle: Started")
+	})
+	lifecycle.SetOnStopped(func() {
+		log.Println("Lifecycle: Stopped")
+		w.saveSession()
+		w.closeAll()
+	})
+	lifecycle.SetOnEnteredForeground(func() {
+		log.Println("Lifecycle: Entered Foreground")
+		if repo := w.active(); repo != nil {
+			repo.refreshFileList()
+			repo.refreshDiffView()
+		}
+	})
+	lifecycle.SetOnExitedForeground(func() {
+		log.Println("Lifecycle: Exited Foreground")
+	})
+}
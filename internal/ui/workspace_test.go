@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+// newTestWorkspace builds a workspace on Fyne's test app that closes its
+// repositories when the test ends
+func newTestWorkspace(t *testing.T, application fyne.App) *Workspace {
+	t.Helper()
+	w := newWorkspace(application)
+	t.Cleanup(w.closeAll)
+	return w
+}
+
+// newEmptyRepository creates a repository without commits named name
+func newEmptyRepository(t *testing.T, name string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, output)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	mustSucceed(t, err)
+	return resolved
+}
+
+func TestSessionRestoresTabs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	application := test.NewTempApp(t)
+	first, second, removed := newEmptyRepository(t, "first"), newEmptyRepository(t, "second"), newEmptyRepository(t, "removed")
+
+	w := newTestWorkspace(t, application)
+	for _, path := range []string{removed, first, second} {
+		_, err := w.Open(path)
+		mustSucceed(t, err)
+	}
+	// The session is saved without closing the window
+	w.tabs.SelectIndex(1)
+	mustSucceed(t, os.RemoveAll(removed))
+
+	restored := newTestWorkspace(t, application)
+	restored.restore()
+	if len(restored.repos) != 2 || restored.repos[0].git.WorkingDir != first || restored.repos[1].git.WorkingDir != second {
+		t.Fatalf("restored repositories are %v, want %s and %s", restored.repos, first, second)
+	}
+	if active := restored.active(); active == nil || active.git.WorkingDir != first {
+		t.Errorf("restored selected tab is %v, want %s", active, first)
+	}
+}
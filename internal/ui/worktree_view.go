@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gleam/internal/git"
+)
+
+// worktreeState summarizes the branch and the locked and prunable state of a
+// worktree
+func worktreeState(worktree git.Worktree) string {
+	parts := make([]string, 0, 3)
+	switch {
+	case worktree.Bare:
+		parts = append(parts, "bare")
+	case worktree.Branch != "":
+		parts = append(parts, worktree.Branch)
+	default:
+		parts = append(parts, "detached at "+shortHash(worktree.Head))
+	}
+	if worktree.Locked {
+		parts = append(parts, strings.TrimSpace("locked "+worktree.LockReason))
+	}
+	if worktree.Prunable {
+		parts = append(parts, strings.TrimSpace("prunable "+worktree.PrunableReason))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// showWorktrees opens a window listing the worktrees of the repository, to
+// create, remove, prune and open them
+func (app *GleamApp) showWorktrees() {
+	worktrees, err := app.git.Worktrees()
+	if err != nil {
+		dialog.ShowError(err, app.ui.window)
+		return
+	}
+
+	window := app.fyneApp.NewWindow("Worktrees")
+	selected := -1
+
+	list := widget.NewList(
+		func() int { return len(worktrees) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			worktree := worktrees[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(worktree.Path)
+			row.Objects[1].(*widget.Label).SetText(worktreeState(worktree))
+		},
+	)
+
+	reload := func() {
+		reloaded, err := app.git.Worktrees()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		worktrees = reloaded
+		list.UnselectAll()
+		list.Refresh()
+	}
+	run := func(title string, operation func() error, failed func(error)) {
+		progress := dialog.NewProgress(title, title+"...", window)
+		progress.Show()
+		app.tasks.Serial(func(context.Context) error { return operation() }, func(err error) {
+			progress.Hide()
+			switch {
+			case err != nil && failed != nil:
+				failed(err)
+			case err != nil:
+				dialog.ShowError(err, window)
+			}
+			reload()
+		})
+	}
+
+	addButton := widget.NewButtonWithIcon("Add…", theme.ContentAddIcon(), func() {
+		app.showAddWorktree(window, func(opts git.AddWorktreeOptions) {
+			run("Adding worktree", func() error { return app.git.AddWorktree(opts) }, nil)
+		})
+	})
+
+	removeButton := widget.NewButtonWithIcon("Remove", theme.DeleteIcon(), func() {
+		if selected <= 0 {
+			return
+		}
+		path := worktrees[selected].Path
+		remove := func(force bool) func() error {
+			return func() error { return app.git.RemoveWorktree(path, force) }
+		}
+		message := fmt.Sprintf("Remove the worktree at %s? Its directory is deleted, the branch is kept.", path)
+		dialog.ShowConfirm("Remove worktree", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			run("Removing worktree", remove(false), func(err error) {
+				message := fmt.Sprintf("%v\n\nRemove it anyway? Its uncommitted changes are lost.", err)
+				dialog.ShowConfirm("Remove worktree", message, func(confirmed bool) {
+					if confirmed {
+						run("Removing worktree", remove(true), nil)
+					}
+				}, window)
+			})
+		}, window)
+	})
+	removeButton.Disable()
+
+	pruneButton := widget.NewButton("Prune", func() {
+		prunable := make([]string, 0)
+		for _, worktree := range worktrees {
+			if worktree.Prunable {
+				prunable = append(prunable, worktree.Path)
+			}
+		}
+		if len(prunable) == 0 {
+			dialog.ShowInformation("Prune worktrees", "No worktree has lost its directory.", window)
+			return
+		}
+		message := "Forget these worktrees, whose directories no longer exist?\n\n" + strings.Join(prunable, "\n")
+		dialog.ShowConfirm("Prune worktrees", message, func(confirmed bool) {
+			if confirmed {
+				run("Pruning worktrees", app.git.PruneWorktrees, nil)
+			}
+		}, window)
+	})
+
+	openButton := widget.NewButtonWithIcon("Open", theme.FolderOpenIcon(), func() {
+		if selected >= 0 && app.openRepository != nil {
+			app.openRepository(worktrees[selected].Path)
+		}
+	})
+	openButton.Disable()
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		worktree := worktrees[id]
+		// The main worktree cannot be removed
+		if id > 0 {
+			removeButton.Enable()
+		} else {
+			removeButton.Disable()
+		}
+		if app.openRepository != nil && !worktree.Bare && !worktree.Prunable {
+			openButton.Enable()
+		} else {
+			openButton.Disable()
+		}
+	}
+	list.OnUnselected = func(widget.ListItemID) {
+		selected = -1
+		removeButton.Disable()
+		openButton.Disable()
+	}
+
+	buttons := container.NewHBox(addButton, removeButton, pruneButton, openButton)
+	window.SetContent(container.NewBorder(nil, buttons, nil, nil, list))
+	window.Resize(fyne.NewSize(700, 450))
+	window.Show()
+}
+
+// showAddWorktree asks for the directory and branch of a new worktree and
+// passes them to add
+func (app *GleamApp) showAddWorktree(parent fyne.Window, add func(git.AddWorktreeOptions)) {
+	refs, err := app.backend.GetRefs()
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	branches := make([]string, 0)
+	for _, ref := range refs {
+		if ref.Kind == git.RefKindBranch {
+			branches = append(branches, ref.Name)
+		}
+	}
+
+	const (
+		existingBranch = "Existing branch"
+		newBranch      = "New branch"
+	)
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder(filepath.Join(filepath.Dir(app.git.WorkingDir), app.Name()+"-worktree"))
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil || folder == nil {
+				return
+			}
+			pathEntry.SetText(filepath.Join(folder.Path(), app.Name()+"-worktree"))
+		}, parent)
+	})
+
+	branchSelect := widget.NewSelect(branches, nil)
+	branchSelect.PlaceHolder = "Branch to check out"
+	newBranchEntry := widget.NewEntry()
+	newBranchEntry.SetPlaceHolder("Name of the new branch")
+	startSelect := widget.NewSelectEntry(branches)
+	startSelect.SetPlaceHolder("Start point, HEAD if empty")
+
+	kind := widget.NewRadioGroup([]string{existingBranch, newBranch}, func(selected string) {
+		if selected == newBranch {
+			branchSelect.Disable()
+			newBranchEntry.Enable()
+			startSelect.Enable()
+		} else {
+			branchSelect.Enable()
+			newBranchEntry.Disable()
+			startSelect.Disable()
+		}
+	})
+	kind.Horizontal = true
+	kind.Required = true
+	kind.SetSelected(existingBranch)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Directory", container.NewBorder(nil, nil, nil, browseButton, pathEntry)),
+		widget.NewFormItem("", kind),
+		widget.NewFormItem("Branch", branchSelect),
+		widget.NewFormItem("New branch", newBranchEntry),
+		widget.NewFormItem("Start point", startSelect),
+	}
+	form := dialog.NewForm("Add worktree", "Add", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		opts := git.AddWorktreeOptions{Path: pathEntry.Text}
+		if opts.Path == "" {
+			opts.Path = pathEntry.PlaceHolder
+		}
+		if kind.Selected == newBranch {
+			opts.NewBranch, opts.StartPoint = newBranchEntry.Text, startSelect.Text
+		} else {
+			opts.Branch = branchSelect.Selected
+		}
+		add(opts)
+	}, parent)
+	form.Resize(fyne.NewSize(560, 320))
+	form.Show()
+}